@@ -0,0 +1,247 @@
+package watersortpuzzle
+
+import (
+	"bufio"
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CachingSolver remembers solutions of another solver in a local file.
+// Solutions are keyed by EquivalentString, so they are reused for any order of the same flasks.
+// When the cache is full, least recently used solutions are evicted.
+//
+// Every solved state is appended to the file, it is rewritten only when entries are evicted.
+// Cache hits only update recency in memory, so they don't touch the disk.
+// Failure to save the file doesn't fail Solve, it is counted in CacheStats.SaveErrors.
+// CachingSolver is safe for concurrent use, if the wrapped solver is.
+type CachingSolver struct {
+	solver     Solver
+	path       string
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// lru holds *cacheEntry, the most recently used at front.
+	lru   *list.List
+	stats CacheStats
+	// stale is true, when the file has entries not in memory and must be rewritten.
+	stale bool
+}
+
+var _ Solver = (*CachingSolver)(nil)
+
+type CacheStats struct {
+	Hits      int
+	Misses    int
+	Evictions int
+	// SaveErrors is number of solutions, which couldn't be saved to the file.
+	SaveErrors int
+}
+
+type cacheEntry struct {
+	Key      string `json:"key"`
+	Solvable bool   `json:"solvable"`
+	// Steps are numbered in order of flasks in Key.
+	Steps []Step `json:"steps"`
+}
+
+const defaultCacheMaxEntries = 10000
+
+type CachingOption func(solver *CachingSolver)
+
+// CachingWithMaxEntries sets number of solutions kept in cache.
+func CachingWithMaxEntries(maxEntries int) CachingOption {
+	return func(solver *CachingSolver) {
+		solver.maxEntries = maxEntries
+	}
+}
+
+// NewCachingSolver wraps solver with cache stored at path. Existing cache file is loaded.
+func NewCachingSolver(solver Solver, path string, opts ...CachingOption) (*CachingSolver, error) {
+	cachingSolver := &CachingSolver{
+		solver:     solver,
+		path:       path,
+		maxEntries: defaultCacheMaxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+
+	for _, opt := range opts {
+		opt(cachingSolver)
+	}
+
+	if err := cachingSolver.load(); err != nil {
+		return nil, err
+	}
+	return cachingSolver, nil
+}
+
+func (s *CachingSolver) Solve(initialState State) ([]Step, error) {
+	key := initialState.EquivalentString()
+	// Canonical state has flasks in order of EquivalentString.
+	var canonicalState State
+	if err := canonicalState.FromString(key); err != nil {
+		return nil, fmt.Errorf("invalid state: %w", err)
+	}
+
+	s.mu.Lock()
+	if elem, ok := s.entries[key]; ok {
+		s.stats.Hits++
+		s.lru.MoveToFront(elem)
+		entry := elem.Value.(*cacheEntry)
+		s.mu.Unlock()
+
+		if !entry.Solvable {
+			return nil, ErrNotExist
+		}
+		perm, err := Permutation(canonicalState, initialState)
+		if err != nil {
+			return nil, err
+		}
+		return RemapSteps(entry.Steps, perm), nil
+	}
+	s.stats.Misses++
+	s.mu.Unlock()
+
+	steps, err := s.solver.Solve(initialState)
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		return nil, err
+	}
+
+	perm, permErr := Permutation(initialState, canonicalState)
+	if permErr != nil {
+		return nil, permErr
+	}
+	entry := &cacheEntry{Key: key, Solvable: err == nil, Steps: RemapSteps(steps, perm)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.add(entry) {
+		s.stale = true
+	}
+	if saveErr := s.save(entry); saveErr != nil {
+		s.stats.SaveErrors++
+		// The file may be left with a part of the entry.
+		s.stale = true
+	}
+	return steps, err
+}
+
+// Unwrap returns the wrapped solver.
+func (s *CachingSolver) Unwrap() Solver {
+	return s.solver
+}
+
+func (s *CachingSolver) CacheStats() CacheStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// add puts entry to the front of lru. It reports whether other entries were evicted or replaced.
+func (s *CachingSolver) add(entry *cacheEntry) bool {
+	if elem, ok := s.entries[entry.Key]; ok {
+		elem.Value = entry
+		s.lru.MoveToFront(elem)
+		return true
+	}
+
+	var evicted bool
+	s.entries[entry.Key] = s.lru.PushFront(entry)
+	for s.lru.Len() > s.maxEntries {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.entries, oldest.Value.(*cacheEntry).Key)
+		s.stats.Evictions++
+		evicted = true
+	}
+	return evicted
+}
+
+// load reads cache file of JSON lines, ordered from the least recently used entry.
+func (s *CachingSolver) load() error {
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot open cache: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var entry cacheEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("corrupted cache file %q: %w", s.path, err)
+		}
+		if s.add(&entry) {
+			s.stale = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("cannot read cache: %w", err)
+	}
+	// Loading isn't a real eviction.
+	s.stats.Evictions = 0
+	return nil
+}
+
+// save appends entry to the file or rewrites the whole file, if it is stale.
+func (s *CachingSolver) save(entry *cacheEntry) error {
+	if s.stale {
+		if err := s.rewrite(); err != nil {
+			return err
+		}
+		s.stale = false
+		return nil
+	}
+
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("cannot save cache: %w", err)
+	}
+	if err := json.NewEncoder(file).Encode(entry); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("cannot save cache: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("cannot save cache: %w", err)
+	}
+	return nil
+}
+
+// rewrite replaces the file with entries in memory, from the least recently used.
+func (s *CachingSolver) rewrite() error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("cannot save cache: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	writer := bufio.NewWriter(tmpFile)
+	encoder := json.NewEncoder(writer)
+	for elem := s.lru.Back(); elem != nil; elem = elem.Prev() {
+		if err := encoder.Encode(elem.Value); err != nil {
+			_ = tmpFile.Close()
+			return fmt.Errorf("cannot save cache: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("cannot save cache: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("cannot save cache: %w", err)
+	}
+	if err := os.Rename(tmpFile.Name(), s.path); err != nil {
+		return fmt.Errorf("cannot save cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package watersortpuzzle_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/solvertest"
+	"github.com/stretchr/testify/require"
+)
+
+func requireSolves(t *testing.T, state watersortpuzzle.State, steps []watersortpuzzle.Step) {
+	t.Helper()
+
+	var err error
+	for _, step := range steps {
+		state, err = state.Step(step)
+		require.NoError(t, err)
+	}
+	require.True(t, state.IsTerminal())
+}
+
+func TestCachingSolver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.jsonl")
+
+	initialState := solvertest.MustState(t, "GORO;FFRO;PPFO;GPRF;GRGP;;")
+	permutedState := solvertest.MustState(t, ";GRGP;FFRO;;GPRF;PPFO;GORO")
+
+	aStarSolver := watersortpuzzle.NewAStarSolver()
+	solver, err := watersortpuzzle.NewCachingSolver(aStarSolver, path)
+	require.NoError(t, err)
+	require.Equal(t, aStarSolver, solver.Unwrap())
+
+	steps, err := solver.Solve(initialState)
+	require.NoError(t, err)
+	requireSolves(t, initialState, steps)
+
+	permutedSteps, err := solver.Solve(permutedState)
+	require.NoError(t, err)
+	require.Len(t, permutedSteps, len(steps))
+	requireSolves(t, permutedState, permutedSteps)
+	require.Equal(t, watersortpuzzle.CacheStats{Hits: 1, Misses: 1}, solver.CacheStats())
+
+	reopenedSolver, err := watersortpuzzle.NewCachingSolver(watersortpuzzle.NewAStarSolver(), path)
+	require.NoError(t, err)
+
+	cachedSteps, err := reopenedSolver.Solve(initialState)
+	require.NoError(t, err)
+	require.Equal(t, steps, cachedSteps)
+	require.Equal(t, watersortpuzzle.CacheStats{Hits: 1}, reopenedSolver.CacheStats())
+}
+
+func TestCachingSolverEviction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.jsonl")
+	solver, err := watersortpuzzle.NewCachingSolver(watersortpuzzle.NewAStarSolver(), path,
+		watersortpuzzle.CachingWithMaxEntries(1))
+	require.NoError(t, err)
+
+	solvable := solvertest.MustState(t, "O;OOO")
+	unsolvable := solvertest.MustState(t, "GOFP;GOOB;")
+
+	_, err = solver.Solve(unsolvable)
+	require.ErrorIs(t, err, watersortpuzzle.ErrNotExist)
+	_, err = solver.Solve(unsolvable)
+	require.ErrorIs(t, err, watersortpuzzle.ErrNotExist)
+
+	_, err = solver.Solve(solvable)
+	require.NoError(t, err)
+	_, err = solver.Solve(unsolvable)
+	require.ErrorIs(t, err, watersortpuzzle.ErrNotExist)
+
+	require.Equal(t, watersortpuzzle.CacheStats{Hits: 1, Misses: 3, Evictions: 2}, solver.CacheStats())
+}
+
+func TestCachingSolverFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.jsonl")
+	solver, err := watersortpuzzle.NewCachingSolver(watersortpuzzle.NewAStarSolver(), path,
+		watersortpuzzle.CachingWithMaxEntries(2))
+	require.NoError(t, err)
+
+	lines := func() []string {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+	// Solutions are appended, until the first eviction rewrites the file.
+	for i, s := range []string{"O;OOO", "OO;OO", "GOFP;GOOB;"} {
+		state := solvertest.MustState(t, s)
+		_, _ = solver.Solve(state)
+		require.Len(t, lines(), []int{1, 2, 2}[i])
+	}
+	require.Contains(t, lines()[0], `"key":"OO;OO"`)
+}
+
+func TestCachingSolverSaveError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "cache.jsonl")
+	solver, err := watersortpuzzle.NewCachingSolver(watersortpuzzle.NewAStarSolver(), path)
+	require.NoError(t, err)
+
+	state := solvertest.MustState(t, "O;OOO")
+	steps, err := solver.Solve(state)
+	require.NoError(t, err)
+	requireSolves(t, state, steps)
+	require.Equal(t, watersortpuzzle.CacheStats{Misses: 1, SaveErrors: 1}, solver.CacheStats())
+}
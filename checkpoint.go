@@ -0,0 +1,220 @@
+package watersortpuzzle
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ErrNoCheckpoint is returned, when solver has no interrupted search to save.
+var ErrNoCheckpoint = errors.New("no interrupted search")
+
+const (
+	checkpointAlgorithmAStar    = "astar"
+	checkpointAlgorithmDijkstra = "dijkstra"
+	checkpointAlgorithmIDAStar  = "idastar"
+)
+
+// Checkpoint is a snapshot of the search interrupted by context.
+// States are stored in String representation.
+type Checkpoint struct {
+	Algorithm    string `json:"algorithm"`
+	InitialState string `json:"initial_state"`
+	Stats        Stats  `json:"stats"`
+
+	AStar   *AStarCheckpoint   `json:"astar,omitempty"`
+	IDAStar *IDAStarCheckpoint `json:"idastar,omitempty"`
+}
+
+// AStarCheckpoint holds the frontier and parent links of all visited states.
+// Closed set consists of states with parents, which are not in the frontier.
+type AStarCheckpoint struct {
+	Frontier []AStarCheckpointNode    `json:"frontier"`
+	Parents  []AStarCheckpointParents `json:"parents"`
+	// Pushed is number of pushes to heap, it is needed to keep tie-breaking of the search.
+	Pushed int `json:"pushed"`
+}
+
+type AStarCheckpointNode struct {
+	State        string `json:"state"`
+	Distance     int    `json:"distance"`
+	RealDistance int    `json:"real_distance"`
+	Seq          int    `json:"seq"`
+}
+
+type AStarCheckpointParents struct {
+	// Key is EquivalentString of the state.
+	Key      string   `json:"key"`
+	Parents  []string `json:"parents"`
+	Distance int      `json:"distance"`
+}
+
+// IDAStarCheckpoint holds the current bound and DFS stack.
+type IDAStarCheckpoint struct {
+	Bound int `json:"bound"`
+	// Path is the DFS stack from initial state to the state, which is not expanded yet.
+	Path []string `json:"path"`
+	// Frames describe expansion progress of every state in Path except the last one.
+	Frames []IDAStarCheckpointFrame `json:"frames"`
+}
+
+type IDAStarCheckpointFrame struct {
+	// Next is index of successor being expanded, it is the next state in Path.
+	Next int `json:"next"`
+	// MinDistance is the smallest f exceeding bound among already expanded successors.
+	MinDistance int `json:"min_distance"`
+	// PathDependent is true if some successors were skipped as being on the path.
+	PathDependent bool `json:"path_dependent"`
+}
+
+// WriteCheckpoint serializes checkpoint to w.
+func WriteCheckpoint(w io.Writer, checkpoint *Checkpoint) error {
+	if err := json.NewEncoder(w).Encode(checkpoint); err != nil {
+		return fmt.Errorf("cannot write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// ReadCheckpoint deserializes checkpoint written by WriteCheckpoint.
+func ReadCheckpoint(r io.Reader) (*Checkpoint, error) {
+	var checkpoint Checkpoint
+	if err := json.NewDecoder(r).Decode(&checkpoint); err != nil {
+		return nil, fmt.Errorf("cannot read checkpoint: %w", err)
+	}
+	return &checkpoint, nil
+}
+
+func checkpointState(str string) (State, error) {
+	var state State
+	if err := state.FromString(str); err != nil {
+		return nil, fmt.Errorf("invalid state %q in checkpoint: %w", str, err)
+	}
+	return state, nil
+}
+
+func (s *AStarSolver) Checkpoint() (*Checkpoint, error) {
+	if !s.interrupted {
+		return nil, ErrNoCheckpoint
+	}
+
+	astar := &AStarCheckpoint{Pushed: s.heap.pushed}
+	for _, elem := range s.heap.heap {
+		astar.Frontier = append(astar.Frontier, AStarCheckpointNode{
+			State:        elem.elem.String(),
+			Distance:     elem.distance,
+			RealDistance: elem.realDistance,
+			Seq:          elem.seq,
+		})
+	}
+	for key, parents := range s.parents {
+		checkpointParents := AStarCheckpointParents{Key: key, Distance: parents.distance}
+		for _, parent := range parents.parents {
+			checkpointParents.Parents = append(checkpointParents.Parents, parent.String())
+		}
+		astar.Parents = append(astar.Parents, checkpointParents)
+	}
+	sort.Slice(astar.Parents, func(i, j int) bool {
+		return astar.Parents[i].Key < astar.Parents[j].Key
+	})
+
+	return &Checkpoint{
+		Algorithm:    s.algorithm,
+		InitialState: s.initialState.String(),
+		Stats:        s.stats,
+		AStar:        astar,
+	}, nil
+}
+
+func (s *AStarSolver) Resume(ctx context.Context, checkpoint *Checkpoint) ([]Step, error) {
+	// Distances in the frontier are made by heuristic, so it must be the same.
+	if checkpoint.Algorithm != s.algorithm || checkpoint.AStar == nil {
+		return nil, fmt.Errorf("cannot resume %q checkpoint with %s solver", checkpoint.Algorithm, s.algorithm)
+	}
+	initialState, err := checkpointState(checkpoint.InitialState)
+	if err != nil {
+		return nil, err
+	}
+	s.reset(initialState)
+	s.stats = checkpoint.Stats
+
+	for _, checkpointParents := range checkpoint.AStar.Parents {
+		parents := aStarParent{distance: checkpointParents.Distance}
+		for _, parentStr := range checkpointParents.Parents {
+			parent, err := checkpointState(parentStr)
+			if err != nil {
+				return nil, err
+			}
+			parents.parents = append(parents.parents, parent)
+		}
+		s.parents[checkpointParents.Key] = parents
+	}
+
+	for _, node := range checkpoint.AStar.Frontier {
+		state, err := checkpointState(node.State)
+		if err != nil {
+			return nil, err
+		}
+		elem := &distanceHeapElem{
+			distance:     node.Distance,
+			realDistance: node.RealDistance,
+			elem:         state,
+			seq:          node.Seq,
+		}
+		s.heapElems[state.EquivalentString()] = elem
+		s.heap.elemToIndex[elem] = len(s.heap.heap)
+		s.heap.heap = append(s.heap.heap, elem)
+	}
+	heap.Init(s.heap)
+	s.heap.pushed = checkpoint.AStar.Pushed
+
+	return s.search(ctx)
+}
+
+func (s *IDAStarSolver) Checkpoint() (*Checkpoint, error) {
+	if s.interruption == nil {
+		return nil, ErrNoCheckpoint
+	}
+	return &Checkpoint{
+		Algorithm:    checkpointAlgorithmIDAStar,
+		InitialState: s.interruption.Path[0],
+		Stats:        s.stats,
+		IDAStar:      s.interruption,
+	}, nil
+}
+
+func (s *IDAStarSolver) Resume(ctx context.Context, checkpoint *Checkpoint) ([]Step, error) {
+	idaStar := checkpoint.IDAStar
+	if checkpoint.Algorithm != checkpointAlgorithmIDAStar || idaStar == nil {
+		return nil, fmt.Errorf("cannot resume %q checkpoint with IDA*", checkpoint.Algorithm)
+	}
+	if len(idaStar.Path) == 0 || len(idaStar.Frames) != len(idaStar.Path)-1 {
+		return nil, errors.New("invalid IDA* checkpoint: frames don't match path")
+	}
+	initialState, err := checkpointState(checkpoint.InitialState)
+	if err != nil {
+		return nil, err
+	}
+	if initialState.String() != idaStar.Path[0] {
+		return nil, errors.New("invalid IDA* checkpoint: path doesn't start at initial state")
+	}
+
+	// Successors are deterministic, so the path must be reproduced by saved indexes.
+	// Otherwise the checkpoint was made by solver with other options.
+	state := initialState
+	for i, frame := range idaStar.Frames {
+		successors := s.successors(state)
+		if frame.Next < 0 || frame.Next >= len(successors) || successors[frame.Next].str != idaStar.Path[i+1] {
+			return nil, errors.New("checkpoint doesn't match IDA* options")
+		}
+		state = successors[frame.Next].state
+	}
+
+	s.reset(initialState)
+	s.stats = checkpoint.Stats
+	s.resumeFrames = idaStar.Frames
+	return s.search(ctx, idaStar.Bound)
+}
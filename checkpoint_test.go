@@ -0,0 +1,115 @@
+package watersortpuzzle_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/solvertest"
+	"github.com/stretchr/testify/require"
+)
+
+// countdownContext is canceled after given number of checks.
+type countdownContext struct {
+	context.Context
+	checks int
+}
+
+func (c *countdownContext) Err() error {
+	c.checks--
+	if c.checks < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestCheckpointResume(t *testing.T) {
+	testCases := []struct {
+		name      string
+		state     string
+		newSolver func() watersortpuzzle.ResumableSolver
+	}{
+		{
+			name:  "dijkstra",
+			state: "RPPR;BRFR;OGOO;QFGQ;GBQO;BQPB;PFFG;;",
+			newSolver: func() watersortpuzzle.ResumableSolver {
+				return watersortpuzzle.NewDijkstraSolver()
+			},
+		},
+		{
+			name:  "idastar",
+			state: "GRPO;PRFB;OQOB;PGFB;PQRB;QGGR;FFOQ;;",
+			newSolver: func() watersortpuzzle.ResumableSolver {
+				return watersortpuzzle.NewIDAStarSolver(watersortpuzzle.IDAStarWithTranspositionTable(0, watersortpuzzle.ReplaceAlways))
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		tt := testCase
+		t.Run(tt.name, func(t *testing.T) {
+			initialState := solvertest.MustState(t, tt.state)
+
+			solver := tt.newSolver()
+			expectedSteps, err := solver.Solve(initialState)
+			require.NoError(t, err)
+			expectedStats := solver.Stats()
+
+			solver = tt.newSolver()
+			steps, err := solver.SolveContext(&countdownContext{Context: context.Background(), checks: 1}, initialState)
+			var interruptions int
+			var checkpoint *watersortpuzzle.Checkpoint
+			for err != nil {
+				require.ErrorIs(t, err, context.Canceled)
+				interruptions++
+
+				checkpoint, err = solver.Checkpoint()
+				require.NoError(t, err)
+
+				var buf bytes.Buffer
+				require.NoError(t, watersortpuzzle.WriteCheckpoint(&buf, checkpoint))
+				checkpoint, err = watersortpuzzle.ReadCheckpoint(&buf)
+				require.NoError(t, err)
+
+				solver = tt.newSolver()
+				steps, err = solver.Resume(&countdownContext{Context: context.Background(), checks: 1}, checkpoint)
+				if err == nil {
+					break
+				}
+			}
+
+			require.Greater(t, interruptions, 1)
+			require.Equal(t, expectedSteps, steps)
+			require.Equal(t, expectedStats.Steps, solver.Stats().Steps)
+		})
+	}
+}
+
+func TestCheckpointWithoutInterruption(t *testing.T) {
+	initialState := solvertest.MustState(t, "O;OOO")
+
+	solver := watersortpuzzle.NewAStarSolver()
+	_, err := solver.Solve(initialState)
+	require.NoError(t, err)
+
+	_, err = solver.Checkpoint()
+	require.ErrorIs(t, err, watersortpuzzle.ErrNoCheckpoint)
+
+	_, err = watersortpuzzle.NewIDAStarSolver().Resume(context.Background(), &watersortpuzzle.Checkpoint{Algorithm: "astar"})
+	require.Error(t, err)
+}
+
+func TestCheckpointOtherHeuristic(t *testing.T) {
+	initialState := solvertest.MustState(t, "RPPR;BRFR;OGOO;QFGQ;GBQO;BQPB;PFFG;;")
+
+	solver := watersortpuzzle.NewDijkstraSolver()
+	_, err := solver.SolveContext(&countdownContext{Context: context.Background(), checks: 1}, initialState)
+	require.ErrorIs(t, err, context.Canceled)
+	checkpoint, err := solver.Checkpoint()
+	require.NoError(t, err)
+	require.Equal(t, "dijkstra", checkpoint.Algorithm)
+
+	_, err = watersortpuzzle.NewAStarSolver().Resume(context.Background(), checkpoint)
+	require.Error(t, err)
+}
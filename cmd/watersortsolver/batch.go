@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+)
+
+// batchRecord is one line of batch output. Result is omitted on errors.
+type batchRecord struct {
+	index int
+
+	Line  int    `json:"line"`
+	Input string `json:"input"`
+	*watersortpuzzle.Result
+	ElapsedMs float64 `json:"elapsed_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type batchJob struct {
+	index int
+	line  int
+	input string
+	// err is set for lines, which are not positions.
+	err error
+}
+
+// runBatch solves positions of file, one per line, and returns exit code.
+func runBatch(args []string) int {
+	flags := flag.NewFlagSet("batch", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s [flags] batch [flags] [file]\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Solves positions of file or stdin, one per line. Empty lines and lines starting with # are skipped.")
+		fmt.Fprintln(flags.Output(), "Prints JSON line for every position in the same order.")
+		fmt.Fprintln(flags.Output(), "Exits with code 1, if some position is invalid or has no solution.")
+		fmt.Fprintln(flags.Output(), "\nFlags:")
+		flags.PrintDefaults()
+	}
+	workers := flags.Int("workers", 1, "Number of positions solved in parallel")
+	_ = flags.Parse(args)
+
+	if flags.NArg() > 1 || *workers < 1 {
+		flags.Usage()
+		return 2
+	}
+	if *algorithmType == "external" && *scratchDir != "" {
+		fmt.Println("Batch doesn't support --scratch-dir, every position needs its own")
+		return 2
+	}
+
+	input := os.Stdin
+	if path := flags.Arg(0); path != "" && path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Printf("Cannot open input: %s\n", err.Error())
+			return 2
+		}
+		defer file.Close()
+		input = file
+	}
+
+	solvers, err := newBatchSolvers(*workers)
+	if err != nil {
+		fmt.Printf("Cannot create solver: %s\n", err.Error())
+		return 2
+	}
+
+	jobs := make(chan batchJob)
+	records := make(chan batchRecord)
+	var wg sync.WaitGroup
+	for _, solver := range solvers {
+		wg.Add(1)
+		go func(solver watersortpuzzle.Solver) {
+			defer wg.Done()
+			for job := range jobs {
+				records <- solveBatchJob(solver, job)
+			}
+		}(solver)
+	}
+
+	var readErr error
+	go func() {
+		readErr = readBatchJobs(input, jobs)
+		close(jobs)
+		wg.Wait()
+		close(records)
+	}()
+
+	failed := writeBatchRecords(os.Stdout, records)
+	if readErr != nil {
+		fmt.Fprintf(os.Stderr, "Cannot read input: %s\n", readErr.Error())
+		return 2
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// newBatchSolvers returns solver for every worker.
+func newBatchSolvers(workers int) ([]watersortpuzzle.Solver, error) {
+	solvers := make([]watersortpuzzle.Solver, workers)
+	for i := range solvers {
+		solver, err := newAlgorithmSolver()
+		if err != nil {
+			return nil, err
+		}
+		solvers[i] = solver
+	}
+	if *cachePath == "" {
+		return solvers, nil
+	}
+
+	// Workers share the cache file, so they share the cache over a pool of solvers.
+	cachingSolver, err := watersortpuzzle.NewCachingSolver(newSolverPool(solvers), *cachePath)
+	if err != nil {
+		return nil, err
+	}
+	for i := range solvers {
+		solvers[i] = cachingSolver
+	}
+	return solvers, nil
+}
+
+// solverPool is a Solver safe for concurrent use, which solves by any free solver of the pool.
+type solverPool chan watersortpuzzle.Solver
+
+func newSolverPool(solvers []watersortpuzzle.Solver) solverPool {
+	pool := make(solverPool, len(solvers))
+	for _, solver := range solvers {
+		pool <- solver
+	}
+	return pool
+}
+
+func (p solverPool) Solve(initialState watersortpuzzle.State) ([]watersortpuzzle.Step, error) {
+	solver := <-p
+	defer func() { p <- solver }()
+	return solver.Solve(initialState)
+}
+
+func readBatchJobs(r io.Reader, jobs chan<- batchJob) error {
+	scanner := bufio.NewScanner(r)
+	var index, line int
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		job := batchJob{index: index, line: line, input: text}
+		// Positions have no spaces, so the rest of line may be only a comment.
+		if fields := strings.Fields(text); len(fields) > 1 {
+			job.input = fields[0]
+			if !strings.HasPrefix(fields[1], "#") {
+				job.err = fmt.Errorf("unexpected %q after position", fields[1])
+			}
+		}
+		jobs <- job
+		index++
+	}
+	return scanner.Err()
+}
+
+func solveBatchJob(solver watersortpuzzle.Solver, job batchJob) batchRecord {
+	record := batchRecord{index: job.index, Line: job.line, Input: job.input}
+	if job.err != nil {
+		record.Error = job.err.Error()
+		return record
+	}
+
+	var state watersortpuzzle.State
+	if err := state.FromString(job.input); err != nil {
+		record.Error = fmt.Sprintf("invalid puzzle state: %s", err.Error())
+		return record
+	}
+
+	start := time.Now()
+	steps, err := solver.Solve(state)
+	record.ElapsedMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil && !errors.Is(err, watersortpuzzle.ErrNotExist) {
+		record.Error = err.Error()
+		return record
+	}
+
+	record.Result = newResult(solver, steps, err)
+	return record
+}
+
+// writeBatchRecords writes records in order of input lines. It returns true, if some position wasn't solved.
+func writeBatchRecords(w io.Writer, records <-chan batchRecord) (failed bool) {
+	encoder := json.NewEncoder(w)
+	pending := make(map[int]batchRecord)
+	var next int
+	for record := range records {
+		pending[record.index] = record
+		for {
+			record, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+
+			if record.Error != "" || !record.Solvable {
+				failed = true
+			}
+			if err := encoder.Encode(record); err != nil {
+				fmt.Fprintf(os.Stderr, "Cannot write output: %s\n", err.Error())
+				failed = true
+			}
+		}
+	}
+	return failed
+}
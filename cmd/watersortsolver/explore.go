@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/explore"
+)
+
+func runExplore(args []string) {
+	flags := flag.NewFlagSet("explore", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s [flags] explore [flags]\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Reads a position and explores all positions reachable from it: distances to the goal,")
+		fmt.Fprintln(flags.Output(), "the hardest positions, dead ends and strongly connected components. For small levels only.")
+		fmt.Fprintln(flags.Output(), "\nFlags:")
+		flags.PrintDefaults()
+	}
+	maxStates := flags.Int("max-states", 1000000, "Maximum number of explored positions")
+	hardest := flags.Int("hardest", 5, "Number of the hardest positions to print")
+	dotPath := flags.String("dot", "", "File to save the graph of positions to in GraphViz DOT format")
+	_ = flags.Parse(args)
+	if flags.NArg() != 0 || *hardest < 0 || *maxStates < 1 {
+		flags.Usage()
+		return
+	}
+	explorer := explore.NewExplorer(explore.WithMaxStates(*maxStates))
+
+	var state watersortpuzzle.State
+	if *outputFormat == "json" {
+		var puzzle watersortpuzzle.Puzzle
+		if err := json.NewDecoder(os.Stdin).Decode(&puzzle); err != nil {
+			writeJSON(jsonError{Error: fmt.Sprintf("invalid puzzle: %s", err.Error())})
+			return
+		}
+		state = puzzle.Flasks
+	} else {
+		var ok bool
+		if state, ok = readState(); !ok {
+			return
+		}
+	}
+
+	graph, err := explorer.Explore(state)
+	if err == nil && *dotPath != "" {
+		err = saveDOT(graph, *dotPath)
+	}
+	if err != nil {
+		if *outputFormat == "json" {
+			writeJSON(jsonError{Error: err.Error()})
+		} else {
+			fmt.Printf("Cannot explore puzzle: %s\n", err.Error())
+		}
+		return
+	}
+
+	summary := graph.Summary(*hardest)
+	if *outputFormat == "json" {
+		writeJSON(summary)
+		return
+	}
+	fmt.Print(summary.Report())
+}
+
+func saveDOT(graph *explore.Graph, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("cannot create DOT file: %w", err)
+	}
+	if err := graph.WriteDOT(file); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("cannot write DOT file: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+	"time"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/render"
+)
+
+func runExport(solver watersortpuzzle.Solver, palette watersortpuzzle.Palette, args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "png",
+		`Picture format. Choices: [png, svg] for the position, [storyboard, gif] for the whole solution`)
+	output := flags.String("output", "", "File to write picture to")
+	scale := flags.Int("scale", 1, "Scale of picture, 1 gives 40px wide flasks")
+	columns := flags.Int("columns", 4, "Number of frames in one row of storyboard")
+	delay := flags.Duration("delay", time.Second, "Delay between frames of gif")
+	_ = flags.Parse(args)
+
+	if *output == "" {
+		fmt.Println("Output file must be set with --output")
+		return
+	}
+	switch *format {
+	case "png", "svg", "storyboard", "gif":
+	default:
+		fmt.Printf("Unknown format %q\n", *format)
+		return
+	}
+
+	state, ok := readState()
+	if !ok {
+		return
+	}
+
+	var steps []watersortpuzzle.Step
+	if *format == "storyboard" || *format == "gif" {
+		var err error
+		if steps, err = solver.Solve(state); err != nil {
+			fmt.Printf("Cannot solve puzzle: %s\n", err.Error())
+			return
+		}
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		fmt.Printf("Cannot create output file: %s\n", err.Error())
+		return
+	}
+	defer file.Close()
+
+	renderer := render.NewRenderer(
+		render.WithPalette(palette),
+		render.WithScale(*scale),
+		render.WithColumns(*columns),
+		render.WithDelay(*delay),
+	)
+	if err := export(file, renderer, *format, state, steps); err != nil {
+		fmt.Printf("Cannot export picture: %s\n", err.Error())
+		return
+	}
+	fmt.Printf("Picture saved to %s\n", *output)
+}
+
+func export(w io.Writer, renderer *render.Renderer, format string,
+	state watersortpuzzle.State, steps []watersortpuzzle.Step) error {
+	switch format {
+	case "svg":
+		return renderer.SVG(w, state)
+	case "storyboard":
+		img, err := renderer.Storyboard(state, steps)
+		if err != nil {
+			return err
+		}
+		return png.Encode(w, img)
+	case "gif":
+		return renderer.GIF(w, state, steps)
+	}
+	return renderer.PNG(w, state)
+}
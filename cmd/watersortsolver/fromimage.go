@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/recognize"
+)
+
+func runFromImage(palette watersortpuzzle.Palette, args []string) {
+	flags := flag.NewFlagSet("from-image", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s [flags] from-image <screenshot>\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Prints position found in PNG, JPEG or GIF screenshot of the game.")
+		fmt.Fprintln(flags.Output(), "Colors are named by letters of --palette and the default palette.")
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("Cannot open image: %s\n", err.Error())
+		return
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		fmt.Printf("Cannot decode image: %s\n", err.Error())
+		return
+	}
+
+	result, err := recognize.NewRecognizer(recognize.WithPalette(palette)).Recognize(img)
+	if err != nil {
+		fmt.Printf("Cannot recognize position: %s\n", err.Error())
+		return
+	}
+	fmt.Print(result.Report())
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkositsyn/water-sort-puzzle-solver/generate"
+	"github.com/pkositsyn/water-sort-puzzle-solver/levelpack"
+)
+
+func runGenerate(args []string) {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s [flags] generate [flags]\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Generates pack of random solvable levels. Levels are solved by --algorithm.")
+		fmt.Fprintln(flags.Output(), "With --scramble levels are made by reverse pours from the solved state, they may have partially filled flasks.")
+		fmt.Fprintln(flags.Output(), "\nFlags:")
+		flags.PrintDefaults()
+	}
+	colors := flags.Int("colors", 4, "Number of colors")
+	emptyFlasks := flags.Int("empty", 2, "Number of empty flasks")
+	capacity := flags.Int("capacity", 4, "Number of pieces in a full flask")
+	count := flags.Int("count", 10, "Number of levels")
+	minSteps := flags.Int("min-steps", 1, "Minimum length of optimal solution")
+	maxSteps := flags.Int("max-steps", 0, "Maximum length of optimal solution, 0 means no limit")
+	minScore := flags.Float64("min-score", 0, "Minimum difficulty score, see 'rate' command")
+	maxScore := flags.Float64("max-score", 0, "Maximum difficulty score, 0 means no limit")
+	scramble := flags.Int("scramble", 0, "Number of random reverse pours from the solved state, 0 means random full flasks")
+	attempts := flags.Int("attempts", 1000, "Number of random boards tried for one level")
+	seed := flags.Int64("seed", 0, "Seed of random boards, 0 means random seed. The same seed gives the same pack")
+	name := flags.String("name", "Generated levels", "Name of the pack")
+	output := flags.String("output", "", "File to save pack to, default is stdout")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 0 || *count < 1 {
+		flags.Usage()
+		return
+	}
+	if *algorithmType == "external" && *scratchDir != "" {
+		fmt.Fprintln(os.Stderr, "Generate doesn't support --scratch-dir, every level needs its own")
+		return
+	}
+
+	solver, err := newAlgorithmSolver()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot create solver: %s\n", err.Error())
+		return
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+		fmt.Fprintf(os.Stderr, "Seed: %d\n", *seed)
+	}
+
+	opts := []generate.Option{
+		generate.WithColors(*colors),
+		generate.WithEmptyFlasks(*emptyFlasks),
+		generate.WithCapacity(*capacity),
+		generate.WithSteps(*minSteps, *maxSteps),
+		generate.WithMaxAttempts(*attempts),
+		generate.WithSolver(solver),
+		generate.WithSeed(*seed),
+		generate.WithScramble(*scramble),
+	}
+	if *minScore != 0 || *maxScore != 0 {
+		opts = append(opts, generate.WithScore(*minScore, *maxScore))
+	}
+
+	generator, err := generate.NewGenerator(opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot create generator: %s\n", err.Error())
+		return
+	}
+
+	pack, err := generator.Pack(*name, *count)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot generate pack: %s\n", err.Error())
+		return
+	}
+
+	if *output != "" {
+		err = levelpack.Save(*output, pack)
+	} else {
+		err = levelpack.Write(os.Stdout, pack)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot write pack: %s\n", err.Error())
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+)
+
+func runHint(solver watersortpuzzle.Solver) {
+	state, ok := readState()
+	if !ok {
+		return
+	}
+
+	hinter := watersortpuzzle.NewHinter(watersortpuzzle.HinterWithSolver(solver))
+	step, remaining, err := hinter.Hint(state)
+	switch {
+	case errors.Is(err, watersortpuzzle.ErrSolved):
+		fmt.Println("Puzzle is already solved!")
+	case errors.Is(err, watersortpuzzle.ErrNotExist):
+		fmt.Println("Position is lost, the puzzle cannot be solved from here")
+	case err != nil:
+		fmt.Printf("Cannot find hint: %s\n", err.Error())
+	default:
+		fmt.Printf("Next step: %d %d. Puzzle can be solved in %d steps\n", step.From+1, step.To+1, remaining)
+	}
+}
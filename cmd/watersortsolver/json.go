@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+)
+
+type jsonError struct {
+	Error string `json:"error"`
+}
+
+// runSolveJSON is runSolve for --format json. It reads Puzzle and writes Result or jsonError.
+func runSolveJSON(solver watersortpuzzle.Solver) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var steps []watersortpuzzle.Step
+	var err error
+	if *resumePath != "" {
+		_, steps, err = resume(ctx, solver, io.Discard)
+	} else {
+		var puzzle watersortpuzzle.Puzzle
+		if err := json.NewDecoder(os.Stdin).Decode(&puzzle); err != nil {
+			writeJSON(jsonError{Error: fmt.Sprintf("invalid puzzle: %s", err.Error())})
+			return
+		}
+		steps, err = solve(ctx, solver, puzzle.Flasks)
+	}
+
+	if err != nil && !errors.Is(err, watersortpuzzle.ErrNotExist) {
+		if ctx.Err() != nil && *checkpointPath != "" {
+			writeJSON(jsonError{Error: saveCheckpoint(solver)})
+			return
+		}
+		writeJSON(jsonError{Error: err.Error()})
+		return
+	}
+
+	result := newResult(solver, steps, err)
+	writeJSON(result)
+}
+
+// newResult makes result of solver, err must be nil or ErrNotExist.
+func newResult(solver watersortpuzzle.Solver, steps []watersortpuzzle.Step, err error) *watersortpuzzle.Result {
+	result := &watersortpuzzle.Result{
+		Solvable: err == nil,
+		Steps:    steps,
+		Length:   len(steps),
+		// All algorithms of the program find the shortest solution.
+		Optimal: err == nil,
+	}
+	if result.Steps == nil {
+		result.Steps = []watersortpuzzle.Step{}
+	}
+	if stats, ok := solverStats(solver); ok {
+		result.Stats = &stats
+	}
+	return result
+}
+
+func writeJSON(v interface{}) {
+	if err := json.NewEncoder(os.Stdout).Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot write output: %s\n", err.Error())
+	}
+}
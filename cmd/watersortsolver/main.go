@@ -1,59 +1,283 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
 
 	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
 )
 
 var algorithmType = flag.String("algorithm", "astar",
-	`Algorithm to solve with. Choices: [astar, idastar, dijkstra]`)
+	`Algorithm to solve with. Choices: [astar, idastar, dijkstra, external]`)
+
+var scratchDir = flag.String("scratch-dir", "",
+	`Directory for external algorithm files. Search in the same directory is resumed`)
+
+var checkpointPath = flag.String("checkpoint", "",
+	`File to save search progress to, when the program is interrupted. Supported by astar, idastar and dijkstra`)
+
+var resumePath = flag.String("resume", "",
+	`Checkpoint file to continue interrupted search from. Use the same algorithm as the interrupted search`)
+
+var cachePath = flag.String("cache", "",
+	`File to cache solutions in. Positions solved before are answered from the cache`)
+
+var outputFormat = flag.String("format", "text",
+	`Format of input and output of solving. Choices: [text, json]. See README for JSON format`)
+
+var showBoard = flag.Bool("show-board", false,
+	`Print the board before and after every step of the solution`)
+
+var paletteStr = flag.String("palette", "",
+	`Colors of letters for colored output, e.g. "R=#ff0000,G=#00ff00". Letters not listed use the default palette`)
 
 func main() {
+	flag.Usage = usage
 	flag.Parse()
-	fmt.Println("Input initial puzzle state")
 
-	var initialStateStr string
-	n, err := fmt.Scanln(&initialStateStr)
+	solver, err := newSolver()
 	if err != nil {
-		fmt.Printf("Error getting input: %s\n", err.Error())
+		fmt.Printf("Cannot create solver: %s\n", err.Error())
 		return
 	}
-	if n != 1 {
-		fmt.Printf("Scanned %d values but needed one position\n", n)
+	if *outputFormat != "text" && *outputFormat != "json" {
+		fmt.Printf("Unknown format %q\n", *outputFormat)
+		return
+	}
+	palette, err := watersortpuzzle.ParsePalette(*paletteStr)
+	if err != nil {
+		fmt.Printf("Invalid palette: %s\n", err.Error())
 		return
 	}
 
-	var solver watersortpuzzle.Solver
+	switch command := flag.Arg(0); command {
+	case "":
+		if *outputFormat == "json" {
+			runSolveJSON(solver)
+			return
+		}
+		runSolve(solver, palette)
+	case "hint":
+		runHint(solver)
+	case "play":
+		runPlay(solver, palette, flag.Args()[1:])
+	case "tui":
+		runTUI(solver, palette, flag.Args()[1:])
+	case "export":
+		runExport(solver, palette, flag.Args()[1:])
+	case "from-image":
+		runFromImage(palette, flag.Args()[1:])
+	case "batch":
+		os.Exit(runBatch(flag.Args()[1:]))
+	case "simulate":
+		runSimulate(solver, flag.Args()[1:])
+	case "rate":
+		runRate(flag.Args()[1:])
+	case "explore":
+		runExplore(flag.Args()[1:])
+	case "generate":
+		runGenerate(flag.Args()[1:])
+	default:
+		fmt.Printf("Unknown command %q\n", command)
+		usage()
+	}
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] [command]\n\n", os.Args[0])
+	fmt.Fprintln(out, "Commands:")
+	fmt.Fprintln(out, "  (none)      solve the puzzle and print all steps")
+	fmt.Fprintln(out, "  hint        print the best next step")
+	fmt.Fprintln(out, "  play        play the puzzle interactively, see 'play --help'")
+	fmt.Fprintln(out, "  tui         show the solution in full-screen terminal UI, see 'tui --help'")
+	fmt.Fprintln(out, "  export      save picture of the position or the solution, see 'export --help'")
+	fmt.Fprintln(out, "  batch       solve positions of file, one per line, see 'batch --help'")
+	fmt.Fprintln(out, "  from-image  print position found in screenshot of the game, see 'from-image --help'")
+	fmt.Fprintln(out, "  simulate    play the puzzle many times by novice strategies, see 'simulate --help'")
+	fmt.Fprintln(out, "  rate        print difficulty score of the puzzle, see 'rate --help'")
+	fmt.Fprintln(out, "  explore     analyse all positions reachable from the puzzle, see 'explore --help'")
+	fmt.Fprintln(out, "  generate    print pack of random solvable levels, see 'generate --help'")
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+func runSolve(solver watersortpuzzle.Solver, palette watersortpuzzle.Palette) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var initialState watersortpuzzle.State
+	var steps []watersortpuzzle.Step
+	var err error
+	if *resumePath != "" {
+		initialState, steps, err = resume(ctx, solver, os.Stdout)
+	} else {
+		var ok bool
+		if initialState, ok = readState(); !ok {
+			return
+		}
+		steps, err = solve(ctx, solver, initialState)
+	}
+	if err != nil {
+		if ctx.Err() != nil && *checkpointPath != "" {
+			fmt.Println(saveCheckpoint(solver))
+			return
+		}
+		fmt.Printf("Cannot solve puzzle: %s\n", err.Error())
+		return
+	}
+
+	suffix := ""
+	if stats, ok := solverStats(solver); ok {
+		suffix = fmt.Sprintf(" Algorithm took %d iterations to find solution.", stats.Steps)
+	}
+
+	fmt.Printf("Puzzle solved in %d steps!%s\n", len(steps), suffix)
+	if *showBoard {
+		printSolutionBoards(initialState, steps, palette)
+		return
+	}
+	for _, step := range steps {
+		fmt.Println(step.From+1, step.To+1)
+	}
+}
+
+// printSolutionBoards prints steps with the board after each of them. Colors are used only for terminal.
+func printSolutionBoards(state watersortpuzzle.State, steps []watersortpuzzle.Step, palette watersortpuzzle.Palette) {
+	opts := watersortpuzzle.RenderOptions{ANSI: isTerminal(os.Stdout.Fd()), Palette: palette}
+	fmt.Print(state.Render(opts))
+	for _, step := range steps {
+		var err error
+		if state, err = state.Step(step); err != nil {
+			fmt.Printf("Solver returned invalid solution: %s\n", err.Error())
+			return
+		}
+		fmt.Printf("\n%d %d\n", step.From+1, step.To+1)
+		fmt.Print(state.Render(opts))
+	}
+}
+
+func newSolver() (watersortpuzzle.Solver, error) {
+	solver, err := newAlgorithmSolver()
+	if err != nil || *cachePath == "" {
+		return solver, err
+	}
+	// Checkpoints are made by the search itself, which cache skips.
+	if *checkpointPath != "" || *resumePath != "" {
+		return nil, errors.New("--cache cannot be used with --checkpoint or --resume")
+	}
+	return watersortpuzzle.NewCachingSolver(solver, *cachePath)
+}
+
+// solverStats returns stats of solver or of the solver wrapped by cache.
+func solverStats(solver watersortpuzzle.Solver) (watersortpuzzle.Stats, bool) {
+	if cachingSolver, ok := solver.(*watersortpuzzle.CachingSolver); ok {
+		solver = cachingSolver.Unwrap()
+	}
+	if statsSolver, ok := solver.(watersortpuzzle.SolverWithStats); ok {
+		return statsSolver.Stats(), true
+	}
+	return watersortpuzzle.Stats{}, false
+}
+
+func newAlgorithmSolver() (watersortpuzzle.Solver, error) {
 	switch *algorithmType {
 	case "astar":
-		solver = watersortpuzzle.NewAStarSolver()
+		return watersortpuzzle.NewAStarSolver(), nil
 	case "idastar":
-		solver = watersortpuzzle.NewIDAStarSolver()
+		return watersortpuzzle.NewIDAStarSolver(), nil
 	case "dijkstra":
-		solver = watersortpuzzle.NewDijkstraSolver()
+		return watersortpuzzle.NewDijkstraSolver(), nil
+	case "external":
+		return watersortpuzzle.NewExternalBFSSolver(watersortpuzzle.ExternalBFSWithScratchDir(*scratchDir)), nil
+	}
+	return nil, fmt.Errorf("unknown algorithm %q", *algorithmType)
+}
+
+// readState reads initial state from stdin. Errors are reported to user.
+func readState() (watersortpuzzle.State, bool) {
+	fmt.Println("Input initial puzzle state")
+
+	var initialStateStr string
+	n, err := fmt.Scanln(&initialStateStr)
+	if err != nil {
+		fmt.Printf("Error getting input: %s\n", err.Error())
+		return nil, false
+	}
+	if n != 1 {
+		fmt.Printf("Scanned %d values but needed one position\n", n)
+		return nil, false
 	}
 
 	var initialState watersortpuzzle.State
 	if err := initialState.FromString(initialStateStr); err != nil {
 		fmt.Printf("Invalid puzzle state provided: %s\n", err.Error())
-		return
+		return nil, false
+	}
+	return initialState, true
+}
+
+func solve(ctx context.Context, solver watersortpuzzle.Solver, initialState watersortpuzzle.State) ([]watersortpuzzle.Step, error) {
+	if resumableSolver, ok := solver.(watersortpuzzle.ResumableSolver); ok {
+		return resumableSolver.SolveContext(ctx, initialState)
+	}
+	return solver.Solve(initialState)
+}
+
+// resume continues search from checkpoint and reports it to log. It returns the initial state of the search too.
+func resume(ctx context.Context, solver watersortpuzzle.Solver, log io.Writer) (watersortpuzzle.State, []watersortpuzzle.Step, error) {
+	resumableSolver, ok := solver.(watersortpuzzle.ResumableSolver)
+	if !ok {
+		return nil, nil, fmt.Errorf("algorithm %s doesn't support checkpoints", *algorithmType)
 	}
 
-	steps, err := solver.Solve(initialState)
+	file, err := os.Open(*resumePath)
 	if err != nil {
-		fmt.Printf("Cannot solve puzzle: %s\n", err.Error())
-		return
+		return nil, nil, err
 	}
+	defer file.Close()
 
-	suffix := ""
-	if statsSolver, ok := solver.(watersortpuzzle.SolverWithStats); ok {
-		suffix = fmt.Sprintf(" Algorithm took %d iterations to find solution.", statsSolver.Stats().Steps)
+	checkpoint, err := watersortpuzzle.ReadCheckpoint(file)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	fmt.Printf("Puzzle solved in %d steps!%s\n", len(steps), suffix)
-	for _, step := range steps {
-		fmt.Println(step.From+1, step.To+1)
+	var initialState watersortpuzzle.State
+	if err := initialState.FromString(checkpoint.InitialState); err != nil {
+		return nil, nil, fmt.Errorf("invalid checkpoint: %w", err)
+	}
+
+	fmt.Fprintf(log, "Resuming search for %s\n", checkpoint.InitialState)
+	steps, err := resumableSolver.Resume(ctx, checkpoint)
+	return initialState, steps, err
+}
+
+// saveCheckpoint saves progress of interrupted search and returns message for user.
+func saveCheckpoint(solver watersortpuzzle.Solver) string {
+	resumableSolver, ok := solver.(watersortpuzzle.ResumableSolver)
+	if !ok {
+		return fmt.Sprintf("Search interrupted, algorithm %s doesn't support checkpoints", *algorithmType)
+	}
+
+	checkpoint, err := resumableSolver.Checkpoint()
+	if err != nil {
+		return fmt.Sprintf("Search interrupted, cannot make checkpoint: %s", err.Error())
+	}
+
+	file, err := os.Create(*checkpointPath)
+	if err != nil {
+		return fmt.Sprintf("Search interrupted, cannot save checkpoint: %s", err.Error())
+	}
+	defer file.Close()
+
+	if err := watersortpuzzle.WriteCheckpoint(file, checkpoint); err != nil {
+		return fmt.Sprintf("Search interrupted, cannot save checkpoint: %s", err.Error())
 	}
+	return fmt.Sprintf("Search interrupted, progress saved to %s", *checkpointPath)
 }
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+)
+
+const playHelp = `Commands:
+  <from> <to>  pour from one flask to another, e.g. "3 5"
+  undo, u      undo the last action
+  redo, r      redo the last undone action
+  add          add an extra empty flask
+  hint         show the best next step
+  distance     show the optimal number of steps left
+  help         show this help
+  quit, q      exit`
+
+func runPlay(solver watersortpuzzle.Solver, palette watersortpuzzle.Palette, args []string) {
+	flags := flag.NewFlagSet("play", flag.ExitOnError)
+	showDistance := flags.Bool("show-distance", false, "Show the optimal number of steps left after every move")
+	extraFlasks := flags.Int("extra-flasks", 1, "How many extra empty flasks can be added")
+	_ = flags.Parse(args)
+
+	state, ok := readState()
+	if !ok {
+		return
+	}
+
+	game := watersortpuzzle.NewGame(state, watersortpuzzle.GameWithExtraFlasks(*extraFlasks))
+	hinter := watersortpuzzle.NewHinter(watersortpuzzle.HinterWithSolver(solver))
+	fmt.Println(playHelp)
+	printGame(game, palette)
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("> ")
+		if !scanner.Scan() {
+			return
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		var err error
+		switch fields[0] {
+		case "quit", "q":
+			return
+		case "help":
+			fmt.Println(playHelp)
+			continue
+		case "hint":
+			printPlayHint(hinter, game.State())
+			continue
+		case "distance":
+			printPlayDistance(hinter, game.State())
+			continue
+		case "undo", "u":
+			err = game.Undo()
+		case "redo", "r":
+			err = game.Redo()
+		case "add":
+			err = game.AddFlask()
+		default:
+			var step watersortpuzzle.Step
+			if step, err = parseStep(fields); err == nil {
+				err = game.Move(step)
+			}
+		}
+		if err != nil {
+			fmt.Printf("Error: %s\n", err.Error())
+			continue
+		}
+
+		printGame(game, palette)
+		if game.IsFinished() {
+			fmt.Printf("Puzzle solved in %d moves!\n", len(game.History()))
+			return
+		}
+		if game.State().IsDeadEnd() {
+			fmt.Println("You're stuck, the puzzle cannot be solved from here. Type undo to go back")
+			continue
+		}
+		if *showDistance {
+			printPlayDistance(hinter, game.State())
+		}
+	}
+}
+
+func parseStep(fields []string) (watersortpuzzle.Step, error) {
+	if len(fields) != 2 {
+		return watersortpuzzle.Step{}, fmt.Errorf("unknown command %q, type help", strings.Join(fields, " "))
+	}
+
+	from, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return watersortpuzzle.Step{}, fmt.Errorf("invalid flask number %q", fields[0])
+	}
+	to, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return watersortpuzzle.Step{}, fmt.Errorf("invalid flask number %q", fields[1])
+	}
+	return watersortpuzzle.Step{From: from - 1, To: to - 1}, nil
+}
+
+// printGame prints the board like solution boards. Colors are used only for terminal.
+func printGame(game *watersortpuzzle.Game, palette watersortpuzzle.Palette) {
+	fmt.Print(game.State().Render(watersortpuzzle.RenderOptions{ANSI: isTerminal(os.Stdout.Fd()), Palette: palette}))
+}
+
+func printPlayHint(hinter *watersortpuzzle.Hinter, state watersortpuzzle.State) {
+	step, remaining, err := hinter.Hint(state)
+	if err != nil {
+		printPlayHintError(err)
+		return
+	}
+	fmt.Printf("Hint: %d %d, then %d more steps\n", step.From+1, step.To+1, remaining-1)
+}
+
+func printPlayDistance(hinter *watersortpuzzle.Hinter, state watersortpuzzle.State) {
+	_, remaining, err := hinter.Hint(state)
+	if err != nil {
+		printPlayHintError(err)
+		return
+	}
+	fmt.Printf("Optimal solution needs %d more steps\n", remaining)
+}
+
+func printPlayHintError(err error) {
+	switch {
+	case errors.Is(err, watersortpuzzle.ErrNotExist):
+		fmt.Println("The puzzle cannot be solved from here. Type undo to go back")
+	default:
+		fmt.Printf("Cannot find hint: %s\n", err.Error())
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/difficulty"
+)
+
+func runRate(args []string) {
+	flags := flag.NewFlagSet("rate", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s [flags] rate [flags]\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Reads a position and prints its difficulty score with the measures it is made of.")
+		fmt.Fprintln(flags.Output(), "All positions reachable from the given one are explored, so it is for small levels only.")
+		fmt.Fprintln(flags.Output(), "\nFlags:")
+		flags.PrintDefaults()
+	}
+	maxStates := flags.Int("max-states", 1000000, "Maximum number of explored positions")
+	_ = flags.Parse(args)
+	if flags.NArg() != 0 || *maxStates < 1 {
+		flags.Usage()
+		return
+	}
+	rater := difficulty.NewRater(difficulty.WithMaxStates(*maxStates))
+
+	if *outputFormat == "json" {
+		var puzzle watersortpuzzle.Puzzle
+		if err := json.NewDecoder(os.Stdin).Decode(&puzzle); err != nil {
+			writeJSON(jsonError{Error: fmt.Sprintf("invalid puzzle: %s", err.Error())})
+			return
+		}
+		rating, err := rater.Rate(puzzle.Flasks)
+		if err != nil {
+			writeJSON(jsonError{Error: err.Error()})
+			return
+		}
+		writeJSON(rating)
+		return
+	}
+
+	state, ok := readState()
+	if !ok {
+		return
+	}
+	rating, err := rater.Rate(state)
+	switch {
+	case errors.Is(err, watersortpuzzle.ErrNotExist):
+		fmt.Println("Position is lost, the puzzle cannot be solved from here")
+	case err != nil:
+		fmt.Printf("Cannot rate puzzle: %s\n", err.Error())
+	default:
+		fmt.Print(rating.Report())
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/simulate"
+)
+
+// simulationResult is JSON output of simulate command.
+type simulationResult struct {
+	Solvable     bool              `json:"solvable"`
+	OptimalSteps int               `json:"optimal_steps"`
+	Reports      []simulate.Report `json:"reports"`
+}
+
+func runSimulate(solver watersortpuzzle.Solver, args []string) {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s [flags] simulate [flags]\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Reads a position and plays it many times by simple strategies of novice players:")
+		fmt.Fprintln(flags.Output(), "random makes any legal move, greedy makes the best looking move,")
+		fmt.Fprintln(flags.Output(), "complete-first finishes a flask whenever possible.")
+		fmt.Fprintln(flags.Output(), "\nFlags:")
+		flags.PrintDefaults()
+	}
+	strategyName := flags.String("strategy", "", "Strategy to simulate, default is all. Choices: [random, greedy, complete-first]")
+	runs := flags.Int("runs", 100, "Number of runs of every strategy")
+	maxMoves := flags.Int("max-moves", 200, "Number of moves, after which run is failed")
+	seed := flags.Int64("seed", 1, "Seed of random choices")
+	_ = flags.Parse(args)
+	if flags.NArg() != 0 || *runs < 1 || *maxMoves < 1 {
+		flags.Usage()
+		return
+	}
+
+	strategies := simulate.Strategies()
+	if *strategyName != "" {
+		strategy, ok := simulate.StrategyByName(*strategyName)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Unknown strategy %q\n", *strategyName)
+			return
+		}
+		strategies = []simulate.Strategy{strategy}
+	}
+
+	var state watersortpuzzle.State
+	if *outputFormat == "json" {
+		var puzzle watersortpuzzle.Puzzle
+		if err := json.NewDecoder(os.Stdin).Decode(&puzzle); err != nil {
+			writeJSON(jsonError{Error: fmt.Sprintf("invalid puzzle: %s", err.Error())})
+			return
+		}
+		state = puzzle.Flasks
+	} else {
+		var ok bool
+		if state, ok = readState(); !ok {
+			return
+		}
+	}
+
+	// Optimal solution shows, how many moves players lose.
+	steps, err := solver.Solve(state)
+	if err != nil && !errors.Is(err, watersortpuzzle.ErrNotExist) {
+		if *outputFormat == "json" {
+			writeJSON(jsonError{Error: err.Error()})
+		} else {
+			fmt.Printf("Cannot solve puzzle: %s\n", err.Error())
+		}
+		return
+	}
+
+	simulator := simulate.NewSimulator(simulate.WithRuns(*runs), simulate.WithMaxMoves(*maxMoves), simulate.WithSeed(*seed))
+	result := simulationResult{Solvable: err == nil, OptimalSteps: len(steps)}
+	for _, strategy := range strategies {
+		result.Reports = append(result.Reports, simulator.Simulate(state, strategy))
+	}
+
+	if *outputFormat == "json" {
+		writeJSON(result)
+		return
+	}
+	if result.Solvable {
+		fmt.Printf("Optimal solution has %d steps\n", result.OptimalSteps)
+	} else {
+		fmt.Println("Puzzle cannot be solved")
+	}
+	for _, report := range result.Reports {
+		fmt.Print(report)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+)
+
+const (
+	enterAltScreen = "\x1b[?1049h"
+	exitAltScreen  = "\x1b[?1049l"
+	hideCursor     = "\x1b[?25l"
+	showCursor     = "\x1b[?25h"
+	clearScreen    = "\x1b[H\x1b[2J"
+	resetStyle     = "\x1b[0m"
+	boldStyle      = "\x1b[1m"
+)
+
+type tuiKey int
+
+const (
+	tuiKeyNone tuiKey = iota
+	tuiKeyNext
+	tuiKeyPrev
+	tuiKeyFirst
+	tuiKeyLast
+	tuiKeyPlay
+	tuiKeyQuit
+)
+
+// solutionView is the state of TUI: solution and position shown.
+type solutionView struct {
+	// states[i] is the state after i steps.
+	states  []watersortpuzzle.State
+	steps   []watersortpuzzle.Step
+	palette watersortpuzzle.Palette
+
+	current int
+	playing bool
+}
+
+func runTUI(solver watersortpuzzle.Solver, palette watersortpuzzle.Palette, args []string) {
+	flags := flag.NewFlagSet("tui", flag.ExitOnError)
+	delay := flags.Duration("delay", 700*time.Millisecond, "Delay between steps, when solution is played")
+	_ = flags.Parse(args)
+
+	state, ok := readState()
+	if !ok {
+		return
+	}
+	steps, err := solver.Solve(state)
+	if err != nil {
+		fmt.Printf("Cannot solve puzzle: %s\n", err.Error())
+		return
+	}
+
+	view := &solutionView{states: []watersortpuzzle.State{state}, steps: steps, palette: palette}
+	for _, step := range steps {
+		if state, err = state.Step(step); err != nil {
+			fmt.Printf("Solver returned invalid solution: %s\n", err.Error())
+			return
+		}
+		view.states = append(view.states, state)
+	}
+
+	// Without raw mode keys are read after Enter, which is still usable.
+	if restore, err := makeRaw(os.Stdin.Fd()); err == nil {
+		defer restore()
+	}
+	fmt.Print(enterAltScreen + hideCursor)
+	defer fmt.Print(showCursor + exitAltScreen)
+
+	keys := make(chan tuiKey)
+	go readKeys(os.Stdin, keys)
+
+	ticker := time.NewTicker(*delay)
+	defer ticker.Stop()
+	// Screen is redrawn only after changes, otherwise idle screen flickers.
+	fmt.Print(view.render())
+	for {
+		select {
+		case key := <-keys:
+			if !view.handleKey(key) {
+				return
+			}
+		case <-ticker.C:
+			if !view.playing {
+				continue
+			}
+			if view.current < len(view.steps) {
+				view.current++
+			}
+			if view.current == len(view.steps) {
+				view.playing = false
+			}
+		}
+		fmt.Print(view.render())
+	}
+}
+
+// handleKey updates view by key. It returns false, when user quits.
+func (v *solutionView) handleKey(key tuiKey) bool {
+	switch key {
+	case tuiKeyQuit:
+		return false
+	case tuiKeyNext:
+		if v.current < len(v.steps) {
+			v.current++
+		}
+	case tuiKeyPrev:
+		if v.current > 0 {
+			v.current--
+		}
+	case tuiKeyFirst:
+		v.current = 0
+	case tuiKeyLast:
+		v.current = len(v.steps)
+	case tuiKeyPlay:
+		v.playing = !v.playing
+		if v.playing && v.current == len(v.steps) {
+			v.current = 0
+		}
+		return true
+	default:
+		// Unknown keys don't stop playing.
+		return true
+	}
+	v.playing = false
+	return true
+}
+
+// readKeys sends keys read from r. When r ends, tuiKeyQuit is sent.
+func readKeys(r io.Reader, keys chan<- tuiKey) {
+	reader := bufio.NewReader(r)
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			keys <- tuiKeyQuit
+			return
+		}
+
+		key := tuiKeyNone
+		switch b {
+		case 'q', 3: // 3 is Ctrl+C in raw mode
+			key = tuiKeyQuit
+		case 'l':
+			key = tuiKeyNext
+		case 'h':
+			key = tuiKeyPrev
+		case 'g':
+			key = tuiKeyFirst
+		case 'G':
+			key = tuiKeyLast
+		case ' ':
+			key = tuiKeyPlay
+		case 0x1b:
+			// Arrows are sent as "ESC [ C" and so on.
+			if next, _ := reader.ReadByte(); next != '[' {
+				continue
+			}
+			switch code, _ := reader.ReadByte(); code {
+			case 'C':
+				key = tuiKeyNext
+			case 'D':
+				key = tuiKeyPrev
+			case 'H':
+				key = tuiKeyFirst
+			case 'F':
+				key = tuiKeyLast
+			}
+		}
+		if key != tuiKeyNone {
+			keys <- key
+		}
+	}
+}
+
+// render draws the current position. Lines end with "\r\n", because raw mode doesn't add "\r".
+func (v *solutionView) render() string {
+	var b strings.Builder
+	b.WriteString(clearScreen)
+	b.WriteString(boldStyle + "Water Sort Puzzle solution" + resetStyle + "\r\n\r\n")
+
+	state := v.states[v.current]
+	var from, to = -1, -1
+	if v.current > 0 {
+		from, to = v.steps[v.current-1].From, v.steps[v.current-1].To
+	}
+
+	for row := watersortpuzzle.FlaskCapacity - 1; row >= 0; row-- {
+		for i := range state {
+			b.WriteString(" │")
+			if c := state[i][row]; c != 0 {
+				b.WriteString(pieceCell(c, v.palette.RGBA(c)))
+			} else {
+				b.WriteString("    ")
+			}
+			b.WriteString("│")
+		}
+		b.WriteString("\r\n")
+	}
+	for range state {
+		b.WriteString(" └────┘")
+	}
+	b.WriteString("\r\n")
+	for i := range state {
+		fmt.Fprintf(&b, "  %3d  ", i+1)
+	}
+	b.WriteString("\r\n")
+	for i := range state {
+		switch i {
+		case from:
+			b.WriteString(boldStyle + "  from " + resetStyle)
+		case to:
+			b.WriteString(boldStyle + "   to  " + resetStyle)
+		default:
+			b.WriteString("       ")
+		}
+	}
+	b.WriteString("\r\n\r\n")
+
+	if v.current == 0 {
+		fmt.Fprintf(&b, "Initial position, %d steps to solve", len(v.steps))
+	} else {
+		fmt.Fprintf(&b, "Step %d/%d: %d -> %d", v.current, len(v.steps), from+1, to+1)
+	}
+	if v.playing {
+		b.WriteString("  [playing]")
+	}
+	b.WriteString("\r\n\r\n")
+	b.WriteString("←/h previous  →/l next  g/G first/last  space play/pause  q quit\r\n")
+	return b.String()
+}
+
+// pieceCell draws a piece with true color background and its letter in contrasting color.
+func pieceCell(c watersortpuzzle.Color, rgba color.RGBA) string {
+	fg := watersortpuzzle.ContrastColor(rgba)
+	return fmt.Sprintf("\x1b[48;2;%d;%d;%dm\x1b[38;2;%d;%d;%dm %c  %s",
+		rgba.R, rgba.G, rgba.B, fg.R, fg.G, fg.B, c, resetStyle)
+}
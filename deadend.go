@@ -0,0 +1,99 @@
+package watersortpuzzle
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrUndecided is returned, when solvability isn't decided within search limit.
+var ErrUndecided = errors.New("cannot decide solvability within search limit")
+
+const defaultSolvableSearchLimit = 100000
+
+// IsDeadEnd reports whether state is lost according to quick checks:
+// number of pieces of some color is not a multiple of flask capacity,
+// or there are no moves left in non-terminal state.
+// Any other dead end is not detected, as it takes a search to find. E.g. in "BCBA;BBAC;CAAC;"
+// piece counts are right and there are moves, but no sequence of them solves the puzzle.
+// So false doesn't mean the state is solvable, use Solvable for that.
+func (s State) IsDeadEnd() bool {
+	colorsCount := make(map[Color]int)
+	for _, f := range s {
+		for _, c := range f[:f.Size()] {
+			colorsCount[c]++
+		}
+	}
+	for _, count := range colorsCount {
+		if count%waterPiecesPerFlask != 0 {
+			return true
+		}
+	}
+
+	if s.IsTerminal() {
+		return false
+	}
+	mp, _, emptyFlasks := s.collectFlasksInfo()
+	return len(emptyFlasks) == 0 && len(s.getNonEmptyFlasksSteps(mp)) == 0
+}
+
+// Solvable reports whether a terminal state is reachable from state.
+// Quick checks of IsDeadEnd go first, then the search of limited number of states.
+// ErrUndecided is returned, when search limit is exceeded.
+func Solvable(state State) (bool, error) {
+	return SolvableWithLimit(state, defaultSolvableSearchLimit)
+}
+
+// SolvableWithLimit is like Solvable, but visits at most limit states.
+func SolvableWithLimit(state State, limit int) (bool, error) {
+	if state.IsDeadEnd() {
+		return false, nil
+	}
+
+	// Any solution is enough, so depth-first search goes to the best looking states first.
+	visited := map[string]struct{}{state.EquivalentString(): {}}
+	stack := []State{state}
+	for len(stack) > 0 {
+		state = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if state.IsTerminal() {
+			return true, nil
+		}
+
+		var successors []State
+		for _, newState := range state.ReachableStates() {
+			key := newState.EquivalentString()
+			if _, ok := visited[key]; ok || newState.IsDeadEnd() {
+				continue
+			}
+			if newState.IsTerminal() {
+				return true, nil
+			}
+			if len(visited) >= limit {
+				return false, ErrUndecided
+			}
+			visited[key] = struct{}{}
+			successors = append(successors, newState)
+		}
+
+		// The best successor must be on top of the stack.
+		heuristics := make([]int, len(successors))
+		for i, newState := range successors {
+			heuristics[i] = newState.Heuristic()
+		}
+		sort.Stable(byHeuristicDesc{states: successors, heuristics: heuristics})
+		stack = append(stack, successors...)
+	}
+	return false, nil
+}
+
+type byHeuristicDesc struct {
+	states     []State
+	heuristics []int
+}
+
+func (b byHeuristicDesc) Len() int           { return len(b.states) }
+func (b byHeuristicDesc) Less(i, j int) bool { return b.heuristics[i] > b.heuristics[j] }
+func (b byHeuristicDesc) Swap(i, j int) {
+	b.states[i], b.states[j] = b.states[j], b.states[i]
+	b.heuristics[i], b.heuristics[j] = b.heuristics[j], b.heuristics[i]
+}
@@ -0,0 +1,66 @@
+package watersortpuzzle_test
+
+import (
+	"testing"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/solvertest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsDeadEnd(t *testing.T) {
+	testCases := []struct {
+		state    string
+		deadEnd  bool
+		solvable bool
+	}{
+		{state: "GOFP;GOOB;", deadEnd: true},
+		{state: "OOOG;GGGO", deadEnd: true},
+		{state: "OOOO;GGGG", solvable: true},
+		{state: "O;OOO", solvable: true},
+		{state: "OGOG;GOGO", deadEnd: true},
+		{state: "OGOG;GOGO;", solvable: true},
+		{state: "BCBA;BBAC;CAAC;", solvable: false},
+		{state: "FORF;OORF;RFOR;;", solvable: true},
+		{state: "YOQG;BHTR;TGPH;WRPY;TWFH;YTQH;VBQO;PBVR;GBFF;OPWV;OYGQ;FVWR;;", solvable: true},
+	}
+
+	for _, testCase := range testCases {
+		tt := testCase
+		t.Run(tt.state, func(t *testing.T) {
+			state := solvertest.MustState(t, tt.state)
+			require.Equal(t, tt.deadEnd, state.IsDeadEnd())
+
+			solvable, err := watersortpuzzle.Solvable(state)
+			require.NoError(t, err)
+			require.Equal(t, tt.solvable, solvable)
+		})
+	}
+}
+
+func TestDeadEndNotDetected(t *testing.T) {
+	// Pieces are counted right and moves exist, but no sequence of them solves the puzzle.
+	state := solvertest.MustState(t, "BCBA;BBAC;CAAC;")
+	require.False(t, state.IsDeadEnd())
+	require.NotEmpty(t, state.LegalSteps())
+
+	solvable, err := watersortpuzzle.Solvable(state)
+	require.NoError(t, err)
+	require.False(t, solvable)
+
+	_, err = watersortpuzzle.NewAStarSolver().Solve(state)
+	require.ErrorIs(t, err, watersortpuzzle.ErrNotExist)
+}
+
+func TestSolvableUndecided(t *testing.T) {
+	state := solvertest.MustState(t, "OGOG;GOGO;")
+
+	_, err := watersortpuzzle.SolvableWithLimit(state, 2)
+	require.ErrorIs(t, err, watersortpuzzle.ErrUndecided)
+
+	// Solution found right at the limit is still a solution.
+	state = solvertest.MustState(t, "O;OOO")
+	solvable, err := watersortpuzzle.SolvableWithLimit(state, 1)
+	require.NoError(t, err)
+	require.True(t, solvable)
+}
@@ -0,0 +1,208 @@
+// Package difficulty rates how hard a level feels to a player.
+//
+// Optimal length alone is a poor proxy for it, so rating explores the whole graph
+// of positions reachable from the level by package explore and combines several measures:
+//   - length of the optimal solution;
+//   - number of different optimal solutions, a single one is harder to find;
+//   - share of moves along optimal solutions, after which the level can't be solved;
+//   - average number of moves to choose from along optimal solutions;
+//   - how many extra moves a greedy player needs, who always makes the best looking move.
+//
+// Runs of simulate.Greedy are reported too, but they don't change the score.
+package difficulty
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/explore"
+	"github.com/pkositsyn/water-sort-puzzle-solver/simulate"
+)
+
+// ErrTooManyStates is returned, when the level has more reachable states than the rater explores.
+var ErrTooManyStates = explore.ErrTooManyStates
+
+const defaultMaxStates = 1000000
+
+// simulatedRuns is the number of simulate.Greedy runs. Seed is fixed, so rating is deterministic.
+const simulatedRuns = 20
+
+// Weights of measures in score.
+const (
+	uniquenessWeight = 5
+	trapsWeight      = 10
+	branchingWeight  = 2
+	// greedyFailPenalty is added instead of greedy loss, when greedy player doesn't solve the level.
+	greedyFailPenalty = 10
+)
+
+// Rating is the score of level with measures it is made of.
+type Rating struct {
+	Score     float64   `json:"score"`
+	Breakdown Breakdown `json:"breakdown"`
+
+	// OptimalSteps is the length of the shortest solution.
+	OptimalSteps int `json:"optimal_steps"`
+	// OptimalSolutions is the number of different shortest solutions.
+	// Moves leading to the same position up to order of flasks are counted once.
+	OptimalSolutions int64 `json:"optimal_solutions"`
+	// Moves is the number of moves from positions of optimal solutions.
+	Moves int `json:"moves"`
+	// DeadEndMoves is the number of those moves, after which the level can't be solved.
+	DeadEndMoves int `json:"dead_end_moves"`
+	// BranchingFactor is the average number of moves from positions of optimal solutions.
+	BranchingFactor float64 `json:"branching_factor"`
+	// GreedySolved tells, whether greedy player solves the level, and GreedySteps is the number of its moves.
+	GreedySolved bool `json:"greedy_solved"`
+	GreedySteps  int  `json:"greedy_steps"`
+	// SimulatedSuccessRate is the share of solved runs of simulate.Greedy, which breaks ties at random
+	// and may return to visited positions, and SimulatedAverageMoves is the average number of moves of solved runs.
+	// They are not counted in score.
+	SimulatedSuccessRate  float64 `json:"simulated_success_rate"`
+	SimulatedAverageMoves float64 `json:"simulated_average_moves"`
+	// States is the number of positions reachable from the level.
+	States int `json:"states"`
+}
+
+// Breakdown is the contribution of every measure to score. Score is their sum.
+type Breakdown struct {
+	Length     float64 `json:"length"`
+	Uniqueness float64 `json:"uniqueness"`
+	Traps      float64 `json:"traps"`
+	Branching  float64 `json:"branching"`
+	Greedy     float64 `json:"greedy"`
+}
+
+// Rater rates levels. It is safe for concurrent use.
+type Rater struct {
+	maxStates int
+}
+
+type Option func(r *Rater)
+
+// WithMaxStates limits the number of explored states. Default is one million.
+func WithMaxStates(maxStates int) Option {
+	return func(r *Rater) {
+		r.maxStates = maxStates
+	}
+}
+
+func NewRater(opts ...Option) *Rater {
+	r := &Rater{maxStates: defaultMaxStates}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Rate rates level of initialState. It returns watersortpuzzle.ErrNotExist for unsolvable levels.
+func (r *Rater) Rate(initialState watersortpuzzle.State) (*Rating, error) {
+	if initialState.IsDeadEnd() {
+		return nil, watersortpuzzle.ErrNotExist
+	}
+	g, err := explore.NewExplorer(explore.WithMaxStates(r.maxStates)).Explore(initialState)
+	if err != nil {
+		return nil, err
+	}
+	if g.Distances[0] == explore.Unsolvable {
+		return nil, watersortpuzzle.ErrNotExist
+	}
+
+	rating := &Rating{
+		OptimalSteps:     g.Distances[0],
+		OptimalSolutions: g.CountOptimalSolutions(),
+		States:           len(g.States),
+	}
+	pathStates := g.OptimalPathStates()
+	for _, i := range pathStates {
+		for _, j := range g.Successors[i] {
+			rating.Moves++
+			if g.Distances[j] == explore.Unsolvable {
+				rating.DeadEndMoves++
+			}
+		}
+	}
+	if len(pathStates) != 0 {
+		rating.BranchingFactor = float64(rating.Moves) / float64(len(pathStates))
+	}
+	rating.GreedySteps, rating.GreedySolved = playGreedy(g)
+	simulated := simulate.NewSimulator(simulate.WithRuns(simulatedRuns)).Simulate(initialState, simulate.Greedy)
+	rating.SimulatedSuccessRate, rating.SimulatedAverageMoves = simulated.SuccessRate, simulated.AverageMoves
+
+	rating.Breakdown = breakdown(rating)
+	b := rating.Breakdown
+	rating.Score = b.Length + b.Uniqueness + b.Traps + b.Branching + b.Greedy
+	return rating, nil
+}
+
+// Rate rates level with default options.
+func Rate(initialState watersortpuzzle.State) (*Rating, error) {
+	return NewRater().Rate(initialState)
+}
+
+func breakdown(rating *Rating) Breakdown {
+	if rating.OptimalSteps == 0 {
+		return Breakdown{}
+	}
+
+	b := Breakdown{
+		Length:     float64(rating.OptimalSteps),
+		Uniqueness: uniquenessWeight / (1 + math.Log2(float64(rating.OptimalSolutions))),
+		Traps:      trapsWeight * float64(rating.DeadEndMoves) / float64(rating.Moves),
+		Branching:  branchingWeight * math.Log2(rating.BranchingFactor),
+		Greedy:     greedyFailPenalty,
+	}
+	if rating.GreedySolved {
+		b.Greedy = float64(rating.GreedySteps - rating.OptimalSteps)
+	}
+	return b
+}
+
+// playGreedy plays moves to positions of the lowest heuristic, never visiting a position twice.
+// It returns number of moves and whether the level is solved.
+func playGreedy(g *explore.Graph) (int, bool) {
+	visited := map[int]struct{}{0: {}}
+	var steps int
+	for i := 0; !g.States[i].IsTerminal(); steps++ {
+		best, bestHeuristic := -1, 0
+		for _, j := range g.Successors[i] {
+			if _, ok := visited[j]; ok {
+				continue
+			}
+			if heuristic := g.States[j].Heuristic(); best == -1 || heuristic < bestHeuristic {
+				best, bestHeuristic = j, heuristic
+			}
+		}
+		if best == -1 {
+			return steps, false
+		}
+		visited[best] = struct{}{}
+		i = best
+	}
+	return steps, true
+}
+
+// Report is human-readable description of rating.
+func (r *Rating) Report() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Difficulty score: %.1f\n", r.Score)
+	fmt.Fprintf(&b, "  length      %5.1f  optimal solution has %d steps\n", r.Breakdown.Length, r.OptimalSteps)
+	fmt.Fprintf(&b, "  uniqueness  %5.1f  %d optimal solutions\n", r.Breakdown.Uniqueness, r.OptimalSolutions)
+	fmt.Fprintf(&b, "  traps       %5.1f  %d of %d moves along optimal solutions lose\n", r.Breakdown.Traps, r.DeadEndMoves, r.Moves)
+	fmt.Fprintf(&b, "  branching   %5.1f  %.1f moves per position along optimal solutions\n", r.Breakdown.Branching, r.BranchingFactor)
+	if r.GreedySolved {
+		fmt.Fprintf(&b, "  greedy      %5.1f  greedy player solves in %d steps\n", r.Breakdown.Greedy, r.GreedySteps)
+	} else {
+		fmt.Fprintf(&b, "  greedy      %5.1f  greedy player gets stuck after %d steps\n", r.Breakdown.Greedy, r.GreedySteps)
+	}
+	fmt.Fprintf(&b, "Not in score: greedy player with random ties solves %.0f%% of runs", r.SimulatedSuccessRate*100)
+	if r.SimulatedSuccessRate != 0 {
+		fmt.Fprintf(&b, " in %.1f steps on average", r.SimulatedAverageMoves)
+	}
+	b.WriteByte('\n')
+	fmt.Fprintf(&b, "%d reachable positions\n", r.States)
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package difficulty_test
+
+import (
+	"errors"
+	"testing"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/difficulty"
+	"github.com/pkositsyn/water-sort-puzzle-solver/solvertest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateSimple(t *testing.T) {
+	rating, err := difficulty.Rate(solvertest.MustState(t, "O;OOO"))
+	require.NoError(t, err)
+	require.Equal(t, &difficulty.Rating{
+		Score:                 6,
+		Breakdown:             difficulty.Breakdown{Length: 1, Uniqueness: 5},
+		OptimalSteps:          1,
+		OptimalSolutions:      1,
+		Moves:                 1,
+		BranchingFactor:       1,
+		GreedySolved:          true,
+		GreedySteps:           1,
+		SimulatedSuccessRate:  1,
+		SimulatedAverageMoves: 1,
+		States:                2,
+	}, rating)
+
+	rating, err = difficulty.Rate(solvertest.MustState(t, "OOOO;"))
+	require.NoError(t, err)
+	require.Zero(t, rating.Score)
+	require.Equal(t, int64(1), rating.OptimalSolutions)
+}
+
+func TestRateLevel(t *testing.T) {
+	state := solvertest.MustState(t, "FORF;OORF;RFOR;;")
+	steps, err := watersortpuzzle.NewAStarSolver().Solve(state)
+	require.NoError(t, err)
+
+	rating, err := difficulty.Rate(state)
+	require.NoError(t, err)
+	require.Equal(t, len(steps), rating.OptimalSteps)
+	require.Positive(t, rating.OptimalSolutions)
+	require.LessOrEqual(t, rating.DeadEndMoves, rating.Moves)
+	require.GreaterOrEqual(t, rating.BranchingFactor, 1.0)
+	if rating.GreedySolved {
+		require.GreaterOrEqual(t, rating.GreedySteps, rating.OptimalSteps)
+	}
+	if rating.SimulatedSuccessRate != 0 {
+		require.GreaterOrEqual(t, rating.SimulatedAverageMoves, float64(rating.OptimalSteps))
+	}
+
+	b := rating.Breakdown
+	require.InDelta(t, b.Length+b.Uniqueness+b.Traps+b.Branching+b.Greedy, rating.Score, 1e-9)
+	require.Contains(t, rating.Report(), "Difficulty score")
+
+	// One empty flask leaves room for mistakes.
+	harder, err := difficulty.Rate(solvertest.MustState(t, "CCCF;BFAB;BABF;CFAA;"))
+	require.NoError(t, err)
+	require.Positive(t, harder.DeadEndMoves)
+	require.False(t, harder.GreedySolved)
+	require.Greater(t, harder.Score, rating.Score)
+}
+
+func TestRateUnsolvable(t *testing.T) {
+	_, err := difficulty.Rate(solvertest.MustState(t, "AABB;BBAA"))
+	require.True(t, errors.Is(err, watersortpuzzle.ErrNotExist))
+
+	_, err = difficulty.Rate(solvertest.MustState(t, "AB;BA"))
+	require.True(t, errors.Is(err, watersortpuzzle.ErrNotExist))
+}
+
+func TestRateTooManyStates(t *testing.T) {
+	_, err := difficulty.NewRater(difficulty.WithMaxStates(2)).Rate(solvertest.MustState(t, "FORF;OORF;RFOR;;"))
+	require.True(t, errors.Is(err, difficulty.ErrTooManyStates))
+}
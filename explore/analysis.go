@@ -0,0 +1,229 @@
+package explore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+)
+
+// Summary of graph.
+type Summary struct {
+	States   int `json:"states"`
+	Edges    int `json:"edges"`
+	Terminal int `json:"terminal"`
+	// InitialDistance is the length of optimal solution of the initial state or Unsolvable.
+	InitialDistance int `json:"initial_distance"`
+	// Distribution is the number of states at every distance, starting from 0.
+	Distribution []int `json:"distribution"`
+	// DeadEnds is the number of states, from which the level can't be solved.
+	DeadEnds int `json:"dead_ends"`
+	// Stuck is the number of non-terminal states without legal moves. They are dead ends too.
+	Stuck int `json:"stuck"`
+	// Components is the number of strongly connected components.
+	Components       int `json:"components"`
+	LargestComponent int `json:"largest_component"`
+	// Hardest are solvable states of the largest distances.
+	Hardest   []Position     `json:"hardest"`
+	Heuristic HeuristicCheck `json:"heuristic"`
+}
+
+// Position is a state with its distance to the nearest terminal one.
+type Position struct {
+	State    watersortpuzzle.State `json:"state"`
+	Distance int                   `json:"distance"`
+}
+
+// HeuristicCheck compares heuristic with true distances of all solvable states.
+type HeuristicCheck struct {
+	// Inadmissible is the number of states, where heuristic is greater than distance.
+	Inadmissible int `json:"inadmissible"`
+	// Inconsistent is the number of moves between solvable states, where heuristic drops by more than 1.
+	Inconsistent int `json:"inconsistent"`
+	// MeanGap is the average difference of distance and heuristic.
+	MeanGap float64 `json:"mean_gap"`
+}
+
+// Summary sums up graph with the given number of hardest states. Heuristic is checked for State.Heuristic.
+func (g *Graph) Summary(hardest int) *Summary {
+	summary := &Summary{
+		States:          len(g.States),
+		Edges:           g.Edges(),
+		InitialDistance: g.Distances[0],
+		Heuristic:       g.CheckHeuristic(watersortpuzzle.State.Heuristic),
+	}
+	for i, distance := range g.Distances {
+		if distance == Unsolvable {
+			summary.DeadEnds++
+			if len(g.Successors[i]) == 0 {
+				summary.Stuck++
+			}
+			continue
+		}
+		if distance == 0 {
+			summary.Terminal++
+		}
+		for len(summary.Distribution) <= distance {
+			summary.Distribution = append(summary.Distribution, 0)
+		}
+		summary.Distribution[distance]++
+	}
+
+	components := g.Components()
+	summary.Components = len(components)
+	for _, component := range components {
+		if len(component) > summary.LargestComponent {
+			summary.LargestComponent = len(component)
+		}
+	}
+
+	for _, i := range g.Hardest(hardest) {
+		summary.Hardest = append(summary.Hardest, Position{State: g.States[i], Distance: g.Distances[i]})
+	}
+	return summary
+}
+
+// Hardest returns at most n solvable states of the largest distances.
+func (g *Graph) Hardest(n int) []int {
+	var solvable []int
+	for i, distance := range g.Distances {
+		if distance != Unsolvable {
+			solvable = append(solvable, i)
+		}
+	}
+	sort.SliceStable(solvable, func(i, j int) bool {
+		return g.Distances[solvable[i]] > g.Distances[solvable[j]]
+	})
+	if len(solvable) > n {
+		solvable = solvable[:n]
+	}
+	return solvable
+}
+
+// CheckHeuristic compares heuristic with true distances.
+func (g *Graph) CheckHeuristic(heuristic func(watersortpuzzle.State) int) HeuristicCheck {
+	var check HeuristicCheck
+	values := make([]int, len(g.States))
+	for i, state := range g.States {
+		values[i] = heuristic(state)
+	}
+
+	var solvable, gap int
+	for i, distance := range g.Distances {
+		if distance == Unsolvable {
+			continue
+		}
+		solvable++
+		gap += distance - values[i]
+		if values[i] > distance {
+			check.Inadmissible++
+		}
+		for _, j := range g.Successors[i] {
+			if g.Distances[j] != Unsolvable && values[i] > values[j]+1 {
+				check.Inconsistent++
+			}
+		}
+	}
+	if solvable != 0 {
+		check.MeanGap = float64(gap) / float64(solvable)
+	}
+	return check
+}
+
+// Components returns strongly connected components found by Tarjan's algorithm.
+// Components go in reverse topological order, so every move leads into the same
+// or one of the previous components.
+func (g *Graph) Components() [][]int {
+	// index is 1-based, zero means the node isn't visited yet.
+	index := make([]int, len(g.States))
+	low := make([]int, len(g.States))
+	onStack := make([]bool, len(g.States))
+	var stack []int
+	var components [][]int
+	next := 1
+
+	// Recursion is replaced by explicit stack of frames, it would be as deep as graph is large.
+	type frame struct {
+		node int
+		edge int
+	}
+	visit := func(v int) {
+		index[v], low[v] = next, next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+	}
+
+	for root := range g.States {
+		if index[root] != 0 {
+			continue
+		}
+		visit(root)
+		calls := []frame{{node: root}}
+		for len(calls) > 0 {
+			f := &calls[len(calls)-1]
+			v := f.node
+			if f.edge < len(g.Successors[v]) {
+				w := g.Successors[v][f.edge]
+				f.edge++
+				if index[w] == 0 {
+					visit(w)
+					calls = append(calls, frame{node: w})
+				} else if onStack[w] && index[w] < low[v] {
+					low[v] = index[w]
+				}
+				continue
+			}
+
+			calls = calls[:len(calls)-1]
+			if len(calls) > 0 {
+				if parent := calls[len(calls)-1].node; low[v] < low[parent] {
+					low[parent] = low[v]
+				}
+			}
+			if low[v] != index[v] {
+				continue
+			}
+			var component []int
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				component = append(component, w)
+				if w == v {
+					break
+				}
+			}
+			components = append(components, component)
+		}
+	}
+	return components
+}
+
+// Report is human-readable description of summary.
+func (s *Summary) Report() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d reachable positions, %d moves between them\n", s.States, s.Edges)
+	if s.InitialDistance == Unsolvable {
+		b.WriteString("The initial position cannot be solved\n")
+	} else {
+		fmt.Fprintf(&b, "The initial position is solved in %d steps\n", s.InitialDistance)
+	}
+	fmt.Fprintf(&b, "%d terminal positions, %d dead ends, %d of them without moves\n", s.Terminal, s.DeadEnds, s.Stuck)
+	fmt.Fprintf(&b, "%d strongly connected components, the largest has %d positions\n", s.Components, s.LargestComponent)
+
+	b.WriteString("Distance to the goal:\n")
+	for distance, count := range s.Distribution {
+		fmt.Fprintf(&b, "  %3d  %d\n", distance, count)
+	}
+	if len(s.Hardest) != 0 {
+		b.WriteString("Hardest positions:\n")
+		for _, position := range s.Hardest {
+			fmt.Fprintf(&b, "  %3d  %s\n", position.Distance, position.State)
+		}
+	}
+	fmt.Fprintf(&b, "Heuristic: %d inadmissible positions, %d inconsistent moves, %.2f below distance on average\n",
+		s.Heuristic.Inadmissible, s.Heuristic.Inconsistent, s.Heuristic.MeanGap)
+	return b.String()
+}
@@ -0,0 +1,48 @@
+package explore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// dotEscaper escapes labels, any letter may be a color.
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// WriteDOT writes graph in GraphViz DOT format. Nodes are labelled by states and distances,
+// terminal ones are green and dead ends are red. Moves along shortest solutions are bold.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "digraph states {")
+	fmt.Fprintln(out, `  node [shape=box, fontname="monospace"];`)
+	for i, state := range g.States {
+		name := dotEscaper.Replace(state.String())
+		label := fmt.Sprintf("%s\\nd=%d", name, g.Distances[i])
+		if g.Distances[i] == Unsolvable {
+			label = fmt.Sprintf("%s\\nlost", name)
+		}
+		attrs := ""
+		switch {
+		case g.Distances[i] == 0:
+			attrs = `, style=filled, fillcolor="palegreen"`
+		case g.Distances[i] == Unsolvable:
+			attrs = `, style=filled, fillcolor="lightpink"`
+		}
+		if i == 0 {
+			attrs += ", penwidth=3"
+		}
+		fmt.Fprintf(out, "  %d [label=\"%s\"%s];\n", i, label, attrs)
+	}
+	for i, successors := range g.Successors {
+		for _, j := range successors {
+			if g.Distances[i] != Unsolvable && g.Distances[j] == g.Distances[i]-1 {
+				fmt.Fprintf(out, "  %d -> %d [style=bold];\n", i, j)
+				continue
+			}
+			fmt.Fprintf(out, "  %d -> %d;\n", i, j)
+		}
+	}
+	fmt.Fprintln(out, "}")
+	return out.Flush()
+}
@@ -0,0 +1,200 @@
+// Package explore enumerates all positions reachable from a level and analyses them.
+//
+// The whole graph is kept in memory, so it is meant for small levels of up to about 6 colors.
+// Positions equal up to order of flasks are one node of the graph, as they are for solvers.
+// Distances to the nearest terminal position are found by breadth-first search over reversed moves.
+package explore
+
+import (
+	"errors"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+)
+
+// ErrTooManyStates is returned, when the level has more reachable states than the explorer visits.
+var ErrTooManyStates = errors.New("too many reachable states")
+
+// Unsolvable is distance of states, from which no terminal state is reachable.
+const Unsolvable = -1
+
+const defaultMaxStates = 1000000
+
+// Graph of states reachable from the initial one, which is node 0.
+type Graph struct {
+	States []watersortpuzzle.State
+	// Successors of every node, each one once. Several moves may lead to the same node.
+	Successors [][]int
+	// Distances to the nearest terminal state or Unsolvable.
+	Distances []int
+}
+
+// Explorer builds graphs of reachable states. It is safe for concurrent use.
+type Explorer struct {
+	maxStates int
+}
+
+type Option func(e *Explorer)
+
+// WithMaxStates limits the number of explored states. Default is one million.
+func WithMaxStates(maxStates int) Option {
+	return func(e *Explorer) {
+		e.maxStates = maxStates
+	}
+}
+
+func NewExplorer(opts ...Option) *Explorer {
+	e := &Explorer{maxStates: defaultMaxStates}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Explore builds graph of all states reachable from initialState.
+func (e *Explorer) Explore(initialState watersortpuzzle.State) (*Graph, error) {
+	g := &Graph{}
+	index := make(map[string]int)
+	add := func(state watersortpuzzle.State) (int, error) {
+		key := state.EquivalentString()
+		if i, ok := index[key]; ok {
+			return i, nil
+		}
+		if len(g.States) >= e.maxStates {
+			return 0, ErrTooManyStates
+		}
+		index[key] = len(g.States)
+		g.States = append(g.States, state)
+		g.Successors = append(g.Successors, nil)
+		return len(g.States) - 1, nil
+	}
+
+	if _, err := add(initialState); err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(g.States); i++ {
+		seen := make(map[int]struct{})
+		for _, newState := range g.States[i].ReachableStates() {
+			j, err := add(newState)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := seen[j]; ok {
+				continue
+			}
+			seen[j] = struct{}{}
+			g.Successors[i] = append(g.Successors[i], j)
+		}
+	}
+
+	g.computeDistances()
+	return g, nil
+}
+
+// Explore builds graph with default options.
+func Explore(initialState watersortpuzzle.State) (*Graph, error) {
+	return NewExplorer().Explore(initialState)
+}
+
+// computeDistances runs breadth-first search from terminal states over reversed moves.
+func (g *Graph) computeDistances() {
+	predecessors := make([][]int, len(g.States))
+	for i, successors := range g.Successors {
+		for _, j := range successors {
+			predecessors[j] = append(predecessors[j], i)
+		}
+	}
+
+	g.Distances = make([]int, len(g.States))
+	var queue []int
+	for i, state := range g.States {
+		g.Distances[i] = Unsolvable
+		if state.IsTerminal() {
+			g.Distances[i] = 0
+			queue = append(queue, i)
+		}
+	}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		for _, j := range predecessors[i] {
+			if g.Distances[j] == Unsolvable {
+				g.Distances[j] = g.Distances[i] + 1
+				queue = append(queue, j)
+			}
+		}
+	}
+}
+
+// Edges returns the number of edges of graph.
+func (g *Graph) Edges() int {
+	var edges int
+	for _, successors := range g.Successors {
+		edges += len(successors)
+	}
+	return edges
+}
+
+// OptimalPathStates returns non-terminal states of all optimal solutions from the initial state.
+func (g *Graph) OptimalPathStates() []int {
+	if g.Distances[0] == Unsolvable {
+		return nil
+	}
+	visited := map[int]struct{}{0: {}}
+	var result []int
+	for queue := []int{0}; len(queue) > 0; queue = queue[1:] {
+		i := queue[0]
+		if g.Distances[i] == 0 {
+			continue
+		}
+		result = append(result, i)
+		for _, j := range g.Successors[i] {
+			if _, ok := visited[j]; ok || g.Distances[j] != g.Distances[i]-1 {
+				continue
+			}
+			visited[j] = struct{}{}
+			queue = append(queue, j)
+		}
+	}
+	return result
+}
+
+// CountOptimalSolutions counts different shortest paths from the initial state to terminal ones.
+// The count saturates at math.MaxInt64 instead of overflowing.
+func (g *Graph) CountOptimalSolutions() int64 {
+	if g.Distances[0] == Unsolvable {
+		return 0
+	}
+
+	// States are counted in order of distance, so successors go first.
+	byDistance := make([][]int, g.Distances[0]+1)
+	for _, i := range g.OptimalPathStates() {
+		byDistance[g.Distances[i]] = append(byDistance[g.Distances[i]], i)
+	}
+	paths := make(map[int]int64)
+	for distance := 1; distance < len(byDistance); distance++ {
+		for _, i := range byDistance[distance] {
+			var n int64
+			for _, j := range g.Successors[i] {
+				switch {
+				case g.Distances[j] == 0:
+					n++
+				case g.Distances[j] == distance-1:
+					n += paths[j]
+				default:
+					continue
+				}
+				if n < 0 {
+					n = maxCount
+				}
+			}
+			paths[i] = n
+		}
+	}
+	if g.Distances[0] == 0 {
+		return 1
+	}
+	return paths[0]
+}
+
+const maxCount = int64(^uint64(0) >> 1)
@@ -0,0 +1,130 @@
+package explore_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/explore"
+	"github.com/pkositsyn/water-sort-puzzle-solver/solvertest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExploreSimple(t *testing.T) {
+	g, err := explore.Explore(solvertest.MustState(t, "O;OOO"))
+	require.NoError(t, err)
+	require.Len(t, g.States, 2)
+	require.Equal(t, [][]int{{1}, {1}}, g.Successors)
+	require.Equal(t, []int{1, 0}, g.Distances)
+	require.Equal(t, int64(1), g.CountOptimalSolutions())
+
+	summary := g.Summary(5)
+	require.Equal(t, 2, summary.States)
+	require.Equal(t, 1, summary.Terminal)
+	require.Equal(t, 1, summary.InitialDistance)
+	require.Equal(t, []int{1, 1}, summary.Distribution)
+	require.Equal(t, 2, summary.Components)
+	require.Equal(t, "O;OOO", summary.Hardest[0].State.String())
+
+	var dot bytes.Buffer
+	require.NoError(t, g.WriteDOT(&dot))
+	require.Equal(t, `digraph states {
+  node [shape=box, fontname="monospace"];
+  0 [label="O;OOO\nd=1", penwidth=3];
+  1 [label=";OOOO\nd=0", style=filled, fillcolor="palegreen"];
+  0 -> 1 [style=bold];
+  1 -> 1;
+}
+`, dot.String())
+}
+
+func TestExploreLevel(t *testing.T) {
+	state := solvertest.MustState(t, "CCCF;BFAB;BABF;CFAA;")
+	steps, err := watersortpuzzle.NewAStarSolver().Solve(state)
+	require.NoError(t, err)
+
+	g, err := explore.Explore(state)
+	require.NoError(t, err)
+	require.Equal(t, len(steps), g.Distances[0])
+
+	summary := g.Summary(3)
+	require.Positive(t, summary.DeadEnds)
+	require.Len(t, summary.Hardest, 3)
+	require.Equal(t, len(summary.Distribution)-1, summary.Hardest[0].Distance)
+	var solvable int
+	for _, count := range summary.Distribution {
+		solvable += count
+	}
+	require.Equal(t, summary.States, solvable+summary.DeadEnds)
+	require.Contains(t, summary.Report(), "strongly connected components")
+}
+
+func TestHeuristicExhaustively(t *testing.T) {
+	for _, s := range []string{"FORF;OORF;RFOR;;", "CCCF;BFAB;BABF;CFAA;", "RGGG;ORPG;PORO;FPOP;FFFR;;"} {
+		g, err := explore.Explore(solvertest.MustState(t, s))
+		require.NoError(t, err)
+
+		check := g.CheckHeuristic(watersortpuzzle.State.Heuristic)
+		require.Zero(t, check.Inadmissible, s)
+		require.Zero(t, check.Inconsistent, s)
+		require.GreaterOrEqual(t, check.MeanGap, 0.0, s)
+	}
+}
+
+func TestComponents(t *testing.T) {
+	g, err := explore.Explore(solvertest.MustState(t, "FORF;OORF;RFOR;;"))
+	require.NoError(t, err)
+
+	reachable := func(from int) map[int]bool {
+		visited := map[int]bool{from: true}
+		for queue := []int{from}; len(queue) > 0; queue = queue[1:] {
+			for _, j := range g.Successors[queue[0]] {
+				if !visited[j] {
+					visited[j] = true
+					queue = append(queue, j)
+				}
+			}
+		}
+		return visited
+	}
+	reach := make([]map[int]bool, len(g.States))
+	for i := range g.States {
+		reach[i] = reachable(i)
+	}
+
+	componentOf := make(map[int]int)
+	for c, component := range g.Components() {
+		for _, i := range component {
+			_, ok := componentOf[i]
+			require.False(t, ok, "state %d is in two components", i)
+			componentOf[i] = c
+		}
+	}
+	require.Len(t, componentOf, len(g.States))
+	for i := range g.States {
+		for j := range g.States {
+			same := reach[i][j] && reach[j][i]
+			require.Equal(t, same, componentOf[i] == componentOf[j], "states %d and %d", i, j)
+			// Moves lead into the same or previous components.
+			if reach[i][j] {
+				require.GreaterOrEqual(t, componentOf[i], componentOf[j])
+			}
+		}
+	}
+}
+
+func TestExploreTooManyStates(t *testing.T) {
+	_, err := explore.NewExplorer(explore.WithMaxStates(10)).Explore(solvertest.MustState(t, "FORF;OORF;RFOR;;"))
+	require.True(t, errors.Is(err, explore.ErrTooManyStates))
+}
+
+func TestWriteDOTEscapes(t *testing.T) {
+	g, err := explore.Explore(solvertest.MustState(t, `"\\"`))
+	require.NoError(t, err)
+
+	var dot bytes.Buffer
+	require.NoError(t, g.WriteDOT(&dot))
+	require.True(t, strings.Contains(dot.String(), `label="\"\\\\\"\nlost"`), dot.String())
+}
@@ -0,0 +1,559 @@
+package watersortpuzzle
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ExternalBFSSolver is a layered breadth-first search, which keeps states on disk instead of RAM.
+// It is much slower than A*, but proves optimality for levels, which don't fit into memory.
+//
+// Every layer is a file of states sorted by EquivalentString. New states are written in
+// sorted runs, which are merged with the sorted file of all visited states to drop duplicates.
+// Progress is saved after every layer, so a search in the same scratch directory can be resumed.
+type ExternalBFSSolver struct {
+	scratchDir string
+	runSize    int
+	stats      Stats
+}
+
+var _ Solver = (*ExternalBFSSolver)(nil)
+
+const defaultExternalRunSize = 1 << 20
+
+func NewExternalBFSSolver(opts ...ExternalBFSOption) *ExternalBFSSolver {
+	solver := &ExternalBFSSolver{
+		runSize: defaultExternalRunSize,
+	}
+
+	for _, opt := range opts {
+		opt(solver)
+	}
+	return solver
+}
+
+type ExternalBFSOption func(solver *ExternalBFSSolver)
+
+// ExternalBFSWithScratchDir sets directory for search files. Files are kept after search.
+// If directory already holds search progress for the same initial state, search is resumed.
+// By default a temporary directory is used and removed after search.
+func ExternalBFSWithScratchDir(dir string) ExternalBFSOption {
+	return func(solver *ExternalBFSSolver) {
+		solver.scratchDir = dir
+	}
+}
+
+// ExternalBFSWithRunSize sets number of states sorted in memory at once.
+func ExternalBFSWithRunSize(runSize int) ExternalBFSOption {
+	return func(solver *ExternalBFSSolver) {
+		solver.runSize = runSize
+	}
+}
+
+// externalProgress is saved to scratch directory after every complete layer.
+type externalProgress struct {
+	InitialState string `json:"initial_state"`
+	Layers       int    `json:"layers"`
+}
+
+const externalProgressFile = "progress.json"
+
+func (s *ExternalBFSSolver) Solve(initialState State) ([]Step, error) {
+	s.stats = Stats{}
+	dir := s.scratchDir
+	if dir == "" {
+		tmpDir, err := os.MkdirTemp("", "watersortsolver-")
+		if err != nil {
+			return nil, fmt.Errorf("cannot create scratch dir: %w", err)
+		}
+		defer os.RemoveAll(tmpDir)
+		dir = tmpDir
+	}
+
+	progress, err := s.loadProgress(dir, initialState)
+	if err != nil {
+		return nil, err
+	}
+
+	for layer := progress.Layers - 1; ; layer++ {
+		terminal, found, err := s.findTerminal(dir, layer)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			return s.collectPathTo(dir, layer, terminal)
+		}
+
+		newStates, err := s.expand(dir, layer)
+		if err != nil {
+			return nil, err
+		}
+		if newStates == 0 {
+			return nil, ErrNotExist
+		}
+
+		progress.Layers = layer + 2
+		if err := writeExternalProgress(dir, progress); err != nil {
+			return nil, err
+		}
+		// Only the latest visited file is needed to continue.
+		_ = os.Remove(visitedFile(dir, layer))
+	}
+}
+
+func (s *ExternalBFSSolver) Stats() Stats {
+	return s.stats
+}
+
+func layerFile(dir string, layer int) string {
+	return filepath.Join(dir, fmt.Sprintf("layer-%06d", layer))
+}
+
+func visitedFile(dir string, layer int) string {
+	return filepath.Join(dir, fmt.Sprintf("visited-%06d", layer))
+}
+
+func runFile(dir string, run int) string {
+	return filepath.Join(dir, fmt.Sprintf("run-%06d", run))
+}
+
+// loadProgress reads progress from scratch directory or starts a new search there.
+func (s *ExternalBFSSolver) loadProgress(dir string, initialState State) (externalProgress, error) {
+	data, err := os.ReadFile(filepath.Join(dir, externalProgressFile))
+	if err == nil {
+		var progress externalProgress
+		if err := json.Unmarshal(data, &progress); err != nil {
+			return externalProgress{}, fmt.Errorf("invalid progress file: %w", err)
+		}
+		if progress.InitialState != initialState.String() {
+			return externalProgress{}, fmt.Errorf("scratch dir %q holds search for another state %q", dir, progress.InitialState)
+		}
+		return progress, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return externalProgress{}, fmt.Errorf("cannot read progress file: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return externalProgress{}, fmt.Errorf("cannot create scratch dir: %w", err)
+	}
+	key := initialState.EquivalentString()
+	if err := writeRecordsFile(layerFile(dir, 0), [][]string{{key, initialState.String()}}); err != nil {
+		return externalProgress{}, err
+	}
+	if err := writeRecordsFile(visitedFile(dir, 0), [][]string{{key}}); err != nil {
+		return externalProgress{}, err
+	}
+
+	progress := externalProgress{InitialState: initialState.String(), Layers: 1}
+	return progress, writeExternalProgress(dir, progress)
+}
+
+func writeExternalProgress(dir string, progress externalProgress) error {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	tmpPath := filepath.Join(dir, externalProgressFile+".tmp")
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("cannot write progress file: %w", err)
+	}
+	return os.Rename(tmpPath, filepath.Join(dir, externalProgressFile))
+}
+
+func (s *ExternalBFSSolver) findTerminal(dir string, layer int) (State, bool, error) {
+	var terminal State
+	var found bool
+	err := readRecordsFile(layerFile(dir, layer), 2, func(record []string) (bool, error) {
+		state, err := externalState(record[1])
+		if err != nil {
+			return false, err
+		}
+		if state.IsTerminal() {
+			terminal, found = state, true
+			return false, nil
+		}
+		return true, nil
+	})
+	return terminal, found, err
+}
+
+// expand writes the next layer of states and the new visited file.
+// Returns number of states in the new layer.
+func (s *ExternalBFSSolver) expand(dir string, layer int) (int, error) {
+	runs, err := s.writeRuns(dir, layer)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		for i := 0; i < runs; i++ {
+			_ = os.Remove(runFile(dir, i))
+		}
+	}()
+
+	merger, err := newRunMerger(dir, runs)
+	if err != nil {
+		return 0, err
+	}
+	defer merger.Close()
+
+	visited, err := openRecordReader(visitedFile(dir, layer))
+	if err != nil {
+		return 0, err
+	}
+	defer visited.Close()
+
+	newLayer, err := createRecordWriter(layerFile(dir, layer+1) + ".tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer newLayer.Close()
+
+	newVisited, err := createRecordWriter(visitedFile(dir, layer+1) + ".tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer newVisited.Close()
+
+	visitedKey, visitedOK, err := visited.next(1)
+	if err != nil {
+		return 0, err
+	}
+
+	var newStates int
+	var lastKey string
+	for {
+		record, ok, err := merger.next()
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			break
+		}
+		key := record[0]
+		if newStates > 0 && key == lastKey {
+			continue
+		}
+
+		for visitedOK && visitedKey[0] < key {
+			if err := newVisited.write(visitedKey...); err != nil {
+				return 0, err
+			}
+			if visitedKey, visitedOK, err = visited.next(1); err != nil {
+				return 0, err
+			}
+		}
+		if visitedOK && visitedKey[0] == key {
+			continue
+		}
+
+		if err := newLayer.write(record...); err != nil {
+			return 0, err
+		}
+		if err := newVisited.write(key); err != nil {
+			return 0, err
+		}
+		lastKey = key
+		newStates++
+	}
+	for visitedOK {
+		if err := newVisited.write(visitedKey...); err != nil {
+			return 0, err
+		}
+		if visitedKey, visitedOK, err = visited.next(1); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := newLayer.Close(); err != nil {
+		return 0, err
+	}
+	if err := newVisited.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(layerFile(dir, layer+1)+".tmp", layerFile(dir, layer+1)); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(visitedFile(dir, layer+1)+".tmp", visitedFile(dir, layer+1)); err != nil {
+		return 0, err
+	}
+	return newStates, nil
+}
+
+// writeRuns writes successors of layer states into sorted runs of at most runSize states.
+// Returns number of runs written.
+func (s *ExternalBFSSolver) writeRuns(dir string, layer int) (int, error) {
+	var runs int
+	var run [][]string
+	flush := func() error {
+		if len(run) == 0 {
+			return nil
+		}
+		sort.Slice(run, func(i, j int) bool {
+			return run[i][0] < run[j][0]
+		})
+		if err := writeRecordsFile(runFile(dir, runs), run); err != nil {
+			return err
+		}
+		runs++
+		run = run[:0]
+		return nil
+	}
+
+	err := readRecordsFile(layerFile(dir, layer), 2, func(record []string) (bool, error) {
+		state, err := externalState(record[1])
+		if err != nil {
+			return false, err
+		}
+
+		s.stats.Steps++
+		for _, newState := range state.ReachableStates() {
+			run = append(run, []string{newState.EquivalentString(), newState.String()})
+			if len(run) >= s.runSize {
+				if err := flush(); err != nil {
+					return false, err
+				}
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return runs, flush()
+}
+
+// collectPathTo restores steps by searching for a parent of every state in the previous layer.
+func (s *ExternalBFSSolver) collectPathTo(dir string, layer int, state State) ([]Step, error) {
+	steps := make([]Step, layer)
+	for ; layer > 0; layer-- {
+		var parent State
+		var found bool
+		err := readRecordsFile(layerFile(dir, layer-1), 2, func(record []string) (bool, error) {
+			candidate, err := externalState(record[1])
+			if err != nil {
+				return false, err
+			}
+			if step, err := candidate.GetStepTo(state); err == nil {
+				steps[layer-1] = step
+				parent, found = candidate, true
+				return false, nil
+			}
+			return true, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("corrupted layer %d: cannot find parent of %q", layer-1, state.String())
+		}
+		state = parent
+	}
+	return steps, nil
+}
+
+func externalState(str string) (State, error) {
+	var state State
+	if err := state.FromString(str); err != nil {
+		return nil, fmt.Errorf("corrupted state %q in scratch file: %w", str, err)
+	}
+	return state, nil
+}
+
+// runMerger merges sorted runs into one sorted sequence.
+type runMerger struct {
+	readers []*recordReader
+	heap    runHeap
+}
+
+type runHeapElem struct {
+	record []string
+	reader int
+}
+
+type runHeap []runHeapElem
+
+var _ heap.Interface = (*runHeap)(nil)
+
+func (h runHeap) Len() int           { return len(h) }
+func (h runHeap) Less(i, j int) bool { return h[i].record[0] < h[j].record[0] }
+func (h runHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *runHeap) Push(x interface{}) {
+	*h = append(*h, x.(runHeapElem))
+}
+
+func (h *runHeap) Pop() interface{} {
+	top := (*h)[len(*h)-1]
+	*h = (*h)[:len(*h)-1]
+	return top
+}
+
+func newRunMerger(dir string, runs int) (*runMerger, error) {
+	merger := &runMerger{}
+	for i := 0; i < runs; i++ {
+		reader, err := openRecordReader(runFile(dir, i))
+		if err != nil {
+			merger.Close()
+			return nil, err
+		}
+		merger.readers = append(merger.readers, reader)
+
+		record, ok, err := reader.next(2)
+		if err != nil {
+			merger.Close()
+			return nil, err
+		}
+		if ok {
+			heap.Push(&merger.heap, runHeapElem{record: record, reader: i})
+		}
+	}
+	return merger, nil
+}
+
+func (m *runMerger) next() ([]string, bool, error) {
+	if m.heap.Len() == 0 {
+		return nil, false, nil
+	}
+	top := heap.Pop(&m.heap).(runHeapElem)
+
+	record, ok, err := m.readers[top.reader].next(2)
+	if err != nil {
+		return nil, false, err
+	}
+	if ok {
+		heap.Push(&m.heap, runHeapElem{record: record, reader: top.reader})
+	}
+	return top.record, true, nil
+}
+
+func (m *runMerger) Close() {
+	for _, reader := range m.readers {
+		_ = reader.Close()
+	}
+}
+
+// Records are sequences of strings, each prefixed with its length as uvarint.
+
+type recordWriter struct {
+	file   *os.File
+	writer *bufio.Writer
+	closed bool
+}
+
+func createRecordWriter(path string) (*recordWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create scratch file: %w", err)
+	}
+	return &recordWriter{file: file, writer: bufio.NewWriter(file)}, nil
+}
+
+func (w *recordWriter) write(fields ...string) error {
+	var lenBuf [binary.MaxVarintLen64]byte
+	for _, field := range fields {
+		n := binary.PutUvarint(lenBuf[:], uint64(len(field)))
+		if _, err := w.writer.Write(lenBuf[:n]); err != nil {
+			return fmt.Errorf("cannot write scratch file: %w", err)
+		}
+		if _, err := w.writer.WriteString(field); err != nil {
+			return fmt.Errorf("cannot write scratch file: %w", err)
+		}
+	}
+	return nil
+}
+
+func (w *recordWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if err := w.writer.Flush(); err != nil {
+		_ = w.file.Close()
+		return fmt.Errorf("cannot write scratch file: %w", err)
+	}
+	return w.file.Close()
+}
+
+func writeRecordsFile(path string, records [][]string) error {
+	writer, err := createRecordWriter(path)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	for _, record := range records {
+		if err := writer.write(record...); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+type recordReader struct {
+	file   *os.File
+	reader *bufio.Reader
+}
+
+func openRecordReader(path string) (*recordReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open scratch file: %w", err)
+	}
+	return &recordReader{file: file, reader: bufio.NewReader(file)}, nil
+}
+
+// next reads record of given number of fields. Returns false at the end of file.
+func (r *recordReader) next(fields int) ([]string, bool, error) {
+	record := make([]string, fields)
+	for i := range record {
+		length, err := binary.ReadUvarint(r.reader)
+		if err == io.EOF && i == 0 {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("corrupted scratch file %q: %w", r.file.Name(), err)
+		}
+
+		buf := make([]byte, length)
+		if _, err := io.ReadFull(r.reader, buf); err != nil {
+			return nil, false, fmt.Errorf("corrupted scratch file %q: %w", r.file.Name(), err)
+		}
+		record[i] = string(buf)
+	}
+	return record, true, nil
+}
+
+func (r *recordReader) Close() error {
+	return r.file.Close()
+}
+
+// readRecordsFile calls fn for every record in file until fn returns false.
+func readRecordsFile(path string, fields int, fn func(record []string) (bool, error)) error {
+	reader, err := openRecordReader(path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for {
+		record, ok, err := reader.next(fields)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if cont, err := fn(record); err != nil || !cont {
+			return err
+		}
+	}
+}
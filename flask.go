@@ -20,6 +20,9 @@ const (
 
 	// waterPiecesPerFlask must be > 0.
 	waterPiecesPerFlask = 4
+
+	// FlaskCapacity is the number of water pieces in a full flask.
+	FlaskCapacity = waterPiecesPerFlask
 )
 
 type Flask [waterPiecesPerFlask]Color
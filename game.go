@@ -0,0 +1,144 @@
+package watersortpuzzle
+
+import "errors"
+
+var (
+	ErrNothingToUndo  = errors.New("nothing to undo")
+	ErrNothingToRedo  = errors.New("nothing to redo")
+	ErrNoExtraFlasks  = errors.New("no extra flasks left")
+	ErrGameIsFinished = errors.New("game is already finished")
+)
+
+// GameAction is an action of player in Game.
+type GameAction struct {
+	Step Step
+	// AddFlask is true for the "add extra tube" power-up. Step is unused then.
+	AddFlask bool
+}
+
+type gameRecord struct {
+	action GameAction
+	// state is the state before action for undo records, and after it for redo ones.
+	state State
+}
+
+// Game is a play session: the current state with history of actions.
+type Game struct {
+	state State
+	undo  []gameRecord
+	redo  []gameRecord
+
+	extraFlasks int
+}
+
+const defaultExtraFlasks = 1
+
+type GameOption func(game *Game)
+
+// GameWithExtraFlasks sets how many times the "add extra tube" power-up can be used.
+func GameWithExtraFlasks(extraFlasks int) GameOption {
+	return func(game *Game) {
+		game.extraFlasks = extraFlasks
+	}
+}
+
+func NewGame(state State, opts ...GameOption) *Game {
+	game := &Game{
+		state:       state.Copy(),
+		extraFlasks: defaultExtraFlasks,
+	}
+
+	for _, opt := range opts {
+		opt(game)
+	}
+	return game
+}
+
+// State returns copy of the current state.
+func (g *Game) State() State {
+	return g.state.Copy()
+}
+
+// IsFinished reports whether the puzzle is solved.
+func (g *Game) IsFinished() bool {
+	return g.state.IsTerminal()
+}
+
+// History returns actions applied and not undone, from the first one.
+func (g *Game) History() []GameAction {
+	history := make([]GameAction, 0, len(g.undo))
+	for _, record := range g.undo {
+		history = append(history, record.action)
+	}
+	return history
+}
+
+// ExtraFlasksLeft returns how many times AddFlask can be used.
+func (g *Game) ExtraFlasksLeft() int {
+	return g.extraFlasks
+}
+
+// Move applies step by the game rules. Errors of illegal steps are *MoveError.
+func (g *Game) Move(step Step) error {
+	if g.IsFinished() {
+		return ErrGameIsFinished
+	}
+	newState, err := g.state.Step(step)
+	if err != nil {
+		return err
+	}
+	g.apply(GameAction{Step: step}, newState)
+	return nil
+}
+
+// AddFlask is the "add extra tube" power-up, which adds an empty flask to the end.
+func (g *Game) AddFlask() error {
+	if g.IsFinished() {
+		return ErrGameIsFinished
+	}
+	if g.extraFlasks == 0 {
+		return ErrNoExtraFlasks
+	}
+
+	g.extraFlasks--
+	g.apply(GameAction{AddFlask: true}, append(g.state.Copy(), Flask{}))
+	return nil
+}
+
+func (g *Game) apply(action GameAction, newState State) {
+	g.undo = append(g.undo, gameRecord{action: action, state: g.state})
+	g.redo = nil
+	g.state = newState
+}
+
+// Undo reverts the last action. Undoing AddFlask gives the power-up back.
+func (g *Game) Undo() error {
+	if len(g.undo) == 0 {
+		return ErrNothingToUndo
+	}
+
+	record := g.undo[len(g.undo)-1]
+	g.undo = g.undo[:len(g.undo)-1]
+	if record.action.AddFlask {
+		g.extraFlasks++
+	}
+	g.redo = append(g.redo, gameRecord{action: record.action, state: g.state})
+	g.state = record.state
+	return nil
+}
+
+// Redo applies the last undone action again.
+func (g *Game) Redo() error {
+	if len(g.redo) == 0 {
+		return ErrNothingToRedo
+	}
+
+	record := g.redo[len(g.redo)-1]
+	g.redo = g.redo[:len(g.redo)-1]
+	if record.action.AddFlask {
+		g.extraFlasks--
+	}
+	g.undo = append(g.undo, gameRecord{action: record.action, state: g.state})
+	g.state = record.state
+	return nil
+}
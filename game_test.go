@@ -0,0 +1,92 @@
+package watersortpuzzle_test
+
+import (
+	"errors"
+	"testing"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/solvertest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGameIllegalMoves(t *testing.T) {
+	state := solvertest.MustState(t, "GOOO;OGGG;;RR;RRB")
+	game := watersortpuzzle.NewGame(state)
+
+	testCases := []struct {
+		step        watersortpuzzle.Step
+		expectedErr error
+	}{
+		{step: watersortpuzzle.Step{From: 0, To: 5}, expectedErr: watersortpuzzle.ErrNoSuchFlask},
+		{step: watersortpuzzle.Step{From: -1, To: 0}, expectedErr: watersortpuzzle.ErrNoSuchFlask},
+		{step: watersortpuzzle.Step{From: 1, To: 1}, expectedErr: watersortpuzzle.ErrSameFlask},
+		{step: watersortpuzzle.Step{From: 2, To: 0}, expectedErr: watersortpuzzle.ErrEmptyFlask},
+		{step: watersortpuzzle.Step{From: 3, To: 0}, expectedErr: watersortpuzzle.ErrFullFlask},
+		{step: watersortpuzzle.Step{From: 1, To: 4}, expectedErr: watersortpuzzle.ErrColorMismatch},
+		{step: watersortpuzzle.Step{From: 1, To: 3}, expectedErr: watersortpuzzle.ErrColorMismatch},
+		{step: watersortpuzzle.Step{From: 0, To: 3}, expectedErr: watersortpuzzle.ErrColorMismatch},
+	}
+	for _, testCase := range testCases {
+		err := game.Move(testCase.step)
+		require.ErrorIs(t, err, testCase.expectedErr)
+
+		var moveErr *watersortpuzzle.MoveError
+		require.True(t, errors.As(err, &moveErr))
+		require.Equal(t, testCase.step, moveErr.Step)
+	}
+	require.Equal(t, state, game.State())
+
+	require.NoError(t, game.Move(watersortpuzzle.Step{From: 3, To: 2}))
+	err := game.Move(watersortpuzzle.Step{From: 0, To: 2})
+	require.ErrorIs(t, err, watersortpuzzle.ErrColorMismatch)
+}
+
+func TestGamePartialPour(t *testing.T) {
+	state := solvertest.MustState(t, "GOOO;OO;")
+	game := watersortpuzzle.NewGame(state)
+
+	// Only two pieces fit, the third one stays.
+	require.NoError(t, game.Move(watersortpuzzle.Step{From: 0, To: 1}))
+	require.Equal(t, "GO;OOOO;", game.State().String())
+	require.ErrorIs(t, game.Move(watersortpuzzle.Step{From: 0, To: 1}), watersortpuzzle.ErrFullFlask)
+
+	require.NoError(t, game.Undo())
+	require.Equal(t, state, game.State())
+	require.NoError(t, game.Redo())
+	require.Equal(t, "GO;OOOO;", game.State().String())
+
+	require.NoError(t, game.Move(watersortpuzzle.Step{From: 0, To: 2}))
+	require.Equal(t, "G;OOOO;O", game.State().String())
+	require.Len(t, game.History(), 2)
+}
+
+func TestGameUndoRedo(t *testing.T) {
+	state := solvertest.MustState(t, "O;OOO")
+	game := watersortpuzzle.NewGame(state)
+
+	require.ErrorIs(t, game.Undo(), watersortpuzzle.ErrNothingToUndo)
+	require.ErrorIs(t, game.Redo(), watersortpuzzle.ErrNothingToRedo)
+
+	require.NoError(t, game.AddFlask())
+	require.ErrorIs(t, game.AddFlask(), watersortpuzzle.ErrNoExtraFlasks)
+	require.Len(t, game.State(), 3)
+
+	require.NoError(t, game.Move(watersortpuzzle.Step{From: 0, To: 1}))
+	require.True(t, game.IsFinished())
+	require.ErrorIs(t, game.Move(watersortpuzzle.Step{From: 1, To: 2}), watersortpuzzle.ErrGameIsFinished)
+	require.Equal(t, []watersortpuzzle.GameAction{
+		{AddFlask: true},
+		{Step: watersortpuzzle.Step{From: 0, To: 1}},
+	}, game.History())
+
+	require.NoError(t, game.Undo())
+	require.NoError(t, game.Undo())
+	require.Equal(t, state, game.State())
+	require.Equal(t, 1, game.ExtraFlasksLeft())
+
+	require.NoError(t, game.Redo())
+	require.Equal(t, 0, game.ExtraFlasksLeft())
+	require.NoError(t, game.Move(watersortpuzzle.Step{From: 1, To: 0}))
+	require.ErrorIs(t, game.Redo(), watersortpuzzle.ErrNothingToRedo)
+	require.True(t, game.IsFinished())
+}
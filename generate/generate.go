@@ -0,0 +1,305 @@
+// Package generate makes random solvable levels.
+//
+// By default every level is a random shuffle of pieces of several colors into full flasks
+// with some empty flasks after them. Shuffles are solved by an optimal solver,
+// unsolvable ones and ones out of target solution length or difficulty score are thrown away.
+//
+// With WithScramble levels are made from the solved state by random reverse pours instead.
+// They are always solvable, so no time is wasted on unsolvable boards,
+// but flasks of such levels may be partially filled.
+// The optimal solver then gives their true distance, and too short ones are thrown away.
+//
+// Generator with the same seed and options makes the same levels.
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/difficulty"
+	"github.com/pkositsyn/water-sort-puzzle-solver/levelpack"
+)
+
+// ErrNoLevel is returned, when no suitable level is found within attempts limit.
+var ErrNoLevel = errors.New("no suitable level found")
+
+const (
+	defaultColors      = 4
+	defaultEmptyFlasks = 2
+	defaultMaxAttempts = 1000
+)
+
+// Level is a generated level with its optimal solution.
+type Level struct {
+	State    watersortpuzzle.State
+	Solution []watersortpuzzle.Step
+	// Rating is set only when difficulty score is bounded by WithScore.
+	Rating *difficulty.Rating
+}
+
+// Generator makes random levels. It isn't safe for concurrent use.
+type Generator struct {
+	colors      int
+	emptyFlasks int
+	capacity    int
+	minSteps    int
+	maxSteps    int
+	minScore    float64
+	maxScore    float64
+	rater       *difficulty.Rater
+	accept      func(level Level) bool
+	solver      watersortpuzzle.Solver
+	seed        int64
+	maxAttempts int
+	scramble    int
+
+	rand *rand.Rand
+}
+
+type Option func(g *Generator)
+
+// WithColors sets number of colors, every color fills one flask.
+func WithColors(colors int) Option {
+	return func(g *Generator) {
+		g.colors = colors
+	}
+}
+
+// WithEmptyFlasks sets number of empty flasks.
+func WithEmptyFlasks(emptyFlasks int) Option {
+	return func(g *Generator) {
+		g.emptyFlasks = emptyFlasks
+	}
+}
+
+// WithCapacity sets number of pieces in a full flask. Only watersortpuzzle.FlaskCapacity is supported for now.
+func WithCapacity(capacity int) Option {
+	return func(g *Generator) {
+		g.capacity = capacity
+	}
+}
+
+// WithSteps sets bounds of optimal solution length. Zero maxSteps means no upper bound.
+func WithSteps(minSteps, maxSteps int) Option {
+	return func(g *Generator) {
+		g.minSteps = minSteps
+		g.maxSteps = maxSteps
+	}
+}
+
+// WithScore sets bounds of difficulty score, see package difficulty. Zero maxScore means no upper bound.
+// Rating explores all positions reachable from the level, so it is slow for many colors.
+func WithScore(minScore, maxScore float64, opts ...difficulty.Option) Option {
+	return func(g *Generator) {
+		g.minScore = minScore
+		g.maxScore = maxScore
+		g.rater = difficulty.NewRater(opts...)
+	}
+}
+
+// WithAccept sets additional check of levels, which passed all other checks.
+func WithAccept(accept func(level Level) bool) Option {
+	return func(g *Generator) {
+		g.accept = accept
+	}
+}
+
+// WithSolver sets solver to check solvability with. It must find optimal solutions
+// for step bounds to make sense. Default is A*.
+func WithSolver(solver watersortpuzzle.Solver) Option {
+	return func(g *Generator) {
+		g.solver = solver
+	}
+}
+
+// WithSeed sets seed of random shuffles. Default is 1.
+func WithSeed(seed int64) Option {
+	return func(g *Generator) {
+		g.seed = seed
+	}
+}
+
+// WithMaxAttempts sets the number of shuffles tried for one level before ErrNoLevel.
+func WithMaxAttempts(maxAttempts int) Option {
+	return func(g *Generator) {
+		g.maxAttempts = maxAttempts
+	}
+}
+
+// WithScramble makes levels by the number of random reverse pours from the solved state, see Scramble.
+func WithScramble(moves int) Option {
+	return func(g *Generator) {
+		g.scramble = moves
+	}
+}
+
+func NewGenerator(opts ...Option) (*Generator, error) {
+	g := &Generator{
+		colors:      defaultColors,
+		emptyFlasks: defaultEmptyFlasks,
+		capacity:    watersortpuzzle.FlaskCapacity,
+		minSteps:    1,
+		seed:        1,
+		maxAttempts: defaultMaxAttempts,
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	if g.capacity != watersortpuzzle.FlaskCapacity {
+		return nil, fmt.Errorf("%w %d, only %d is supported",
+			watersortpuzzle.ErrUnsupportedCapacity, g.capacity, watersortpuzzle.FlaskCapacity)
+	}
+	if g.colors < 1 || g.colors > len(colorLetters()) {
+		return nil, fmt.Errorf("number of colors must be from 1 to %d, got %d", len(colorLetters()), g.colors)
+	}
+	if g.emptyFlasks < 0 {
+		return nil, fmt.Errorf("number of empty flasks must not be negative, got %d", g.emptyFlasks)
+	}
+	if g.maxSteps != 0 && g.maxSteps < g.minSteps {
+		return nil, fmt.Errorf("max steps %d is less than min steps %d", g.maxSteps, g.minSteps)
+	}
+	if g.maxScore != 0 && g.maxScore < g.minScore {
+		return nil, fmt.Errorf("max score %g is less than min score %g", g.maxScore, g.minScore)
+	}
+	if g.maxAttempts < 1 {
+		return nil, fmt.Errorf("max attempts must be positive, got %d", g.maxAttempts)
+	}
+	if g.scramble < 0 {
+		return nil, fmt.Errorf("number of scramble moves must not be negative, got %d", g.scramble)
+	}
+	if g.solver == nil {
+		g.solver = watersortpuzzle.NewAStarSolver()
+	}
+	g.rand = rand.New(rand.NewSource(g.seed))
+	return g, nil
+}
+
+// Generate returns a new random level, which passes all the checks.
+func (g *Generator) Generate() (Level, error) {
+	for attempt := 0; attempt < g.maxAttempts; attempt++ {
+		level, ok, err := g.try(g.board())
+		if err != nil {
+			return Level{}, err
+		}
+		if ok {
+			return level, nil
+		}
+	}
+	return Level{}, fmt.Errorf("%w in %d attempts", ErrNoLevel, g.maxAttempts)
+}
+
+// Pack returns pack of count levels. Levels equal up to order of flasks are not repeated.
+func (g *Generator) Pack(name string, count int) (*levelpack.Pack, error) {
+	pack := &levelpack.Pack{Name: name}
+	seen := make(map[string]struct{}, count)
+	for len(pack.Levels) < count {
+		level, err := g.generateUnique(seen)
+		if err != nil {
+			return nil, fmt.Errorf("cannot generate level %d: %w", len(pack.Levels)+1, err)
+		}
+		pack.Levels = append(pack.Levels, levelpack.Level{
+			Number:       len(pack.Levels) + 1,
+			State:        level.State,
+			OptimalSteps: len(level.Solution),
+		})
+	}
+	return pack, nil
+}
+
+func (g *Generator) generateUnique(seen map[string]struct{}) (Level, error) {
+	for attempt := 0; attempt < g.maxAttempts; attempt++ {
+		state := g.board()
+		key := state.EquivalentString()
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		level, ok, err := g.try(state)
+		if err != nil {
+			return Level{}, err
+		}
+		if ok {
+			seen[key] = struct{}{}
+			return level, nil
+		}
+	}
+	return Level{}, fmt.Errorf("%w in %d attempts", ErrNoLevel, g.maxAttempts)
+}
+
+// board returns a new random board by the chosen method.
+func (g *Generator) board() watersortpuzzle.State {
+	if g.scramble != 0 {
+		return g.Scramble(g.scramble)
+	}
+	return g.Shuffle()
+}
+
+// Shuffle returns random state of full flasks with empty flasks after them. It may be unsolvable.
+func (g *Generator) Shuffle() watersortpuzzle.State {
+	letters := colorLetters()[:g.colors]
+	pieces := make([]watersortpuzzle.Color, 0, g.colors*g.capacity)
+	for _, c := range letters {
+		for i := 0; i < g.capacity; i++ {
+			pieces = append(pieces, c)
+		}
+	}
+	g.rand.Shuffle(len(pieces), func(i, j int) { pieces[i], pieces[j] = pieces[j], pieces[i] })
+
+	state := make(watersortpuzzle.State, g.colors+g.emptyFlasks)
+	for i, c := range pieces {
+		state[i/g.capacity][i%g.capacity] = c
+	}
+	return state
+}
+
+// try solves state and checks it. Unsolvable state isn't an error.
+func (g *Generator) try(state watersortpuzzle.State) (Level, bool, error) {
+	if state.IsDeadEnd() {
+		return Level{}, false, nil
+	}
+	steps, err := g.solver.Solve(state)
+	if errors.Is(err, watersortpuzzle.ErrNotExist) {
+		return Level{}, false, nil
+	}
+	if err != nil {
+		return Level{}, false, fmt.Errorf("cannot solve %s: %w", state, err)
+	}
+
+	if len(steps) < g.minSteps || (g.maxSteps != 0 && len(steps) > g.maxSteps) {
+		return Level{}, false, nil
+	}
+	level := Level{State: state, Solution: steps}
+	if g.rater != nil {
+		rating, err := g.rater.Rate(state)
+		if err != nil {
+			return Level{}, false, fmt.Errorf("cannot rate %s: %w", state, err)
+		}
+		if rating.Score < g.minScore || (g.maxScore != 0 && rating.Score > g.maxScore) {
+			return Level{}, false, nil
+		}
+		level.Rating = rating
+	}
+	if g.accept != nil && !g.accept(level) {
+		return Level{}, false, nil
+	}
+	return level, true, nil
+}
+
+// colorLetters are letters of DefaultPalette followed by the rest of capital letters.
+func colorLetters() []watersortpuzzle.Color {
+	var letters []watersortpuzzle.Color
+	for c := range watersortpuzzle.DefaultPalette {
+		letters = append(letters, c)
+	}
+	sort.Slice(letters, func(i, j int) bool { return letters[i] < letters[j] })
+	for c := watersortpuzzle.Color('A'); c <= 'Z'; c++ {
+		if _, ok := watersortpuzzle.DefaultPalette[c]; !ok {
+			letters = append(letters, c)
+		}
+	}
+	return letters
+}
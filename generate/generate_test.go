@@ -0,0 +1,136 @@
+package generate_test
+
+import (
+	"errors"
+	"testing"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/generate"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateReproducible(t *testing.T) {
+	generatePack := func(seed int64) []string {
+		g, err := generate.NewGenerator(generate.WithColors(3), generate.WithSeed(seed))
+		require.NoError(t, err)
+		pack, err := g.Pack("Test", 5)
+		require.NoError(t, err)
+
+		var states []string
+		for i, level := range pack.Levels {
+			require.Equal(t, i+1, level.Number)
+			states = append(states, level.State.String())
+		}
+		return states
+	}
+
+	require.Equal(t, generatePack(7), generatePack(7))
+	require.NotEqual(t, generatePack(7), generatePack(8))
+}
+
+func TestGenerateLevel(t *testing.T) {
+	g, err := generate.NewGenerator(
+		generate.WithColors(4),
+		generate.WithEmptyFlasks(2),
+		generate.WithSteps(6, 8),
+	)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		level, err := g.Generate()
+		require.NoError(t, err)
+		require.Len(t, level.State, 6)
+		require.True(t, level.State[4].IsEmpty())
+		require.True(t, level.State[5].IsEmpty())
+		require.GreaterOrEqual(t, len(level.Solution), 6)
+		require.LessOrEqual(t, len(level.Solution), 8)
+
+		state := level.State
+		for _, step := range level.Solution {
+			state, err = state.Step(step)
+			require.NoError(t, err)
+		}
+		require.True(t, state.IsTerminal())
+	}
+}
+
+func TestGenerateAccept(t *testing.T) {
+	var calls int
+	g, err := generate.NewGenerator(generate.WithColors(3), generate.WithAccept(func(level generate.Level) bool {
+		calls++
+		return level.State[0][0] == 'A'
+	}))
+	require.NoError(t, err)
+
+	level, err := g.Generate()
+	require.NoError(t, err)
+	require.Equal(t, watersortpuzzle.Color('A'), level.State[0][0])
+	require.NotZero(t, calls)
+}
+
+func TestGenerateScore(t *testing.T) {
+	g, err := generate.NewGenerator(generate.WithColors(4), generate.WithEmptyFlasks(1), generate.WithScore(25, 0))
+	require.NoError(t, err)
+
+	level, err := g.Generate()
+	require.NoError(t, err)
+	require.NotNil(t, level.Rating)
+	require.GreaterOrEqual(t, level.Rating.Score, 25.0)
+	require.Equal(t, len(level.Solution), level.Rating.OptimalSteps)
+}
+
+func TestScramble(t *testing.T) {
+	solver := watersortpuzzle.NewAStarSolver()
+	for seed := int64(1); seed <= 20; seed++ {
+		g, err := generate.NewGenerator(generate.WithColors(4), generate.WithEmptyFlasks(1), generate.WithSeed(seed))
+		require.NoError(t, err)
+
+		for _, moves := range []int{1, 5, 15} {
+			state := g.Scramble(moves)
+			require.Len(t, state, 5)
+			require.False(t, state.IsDeadEnd(), state.String())
+
+			// Reverse pours can be undone by the same number of forward steps at most.
+			steps, err := solver.Solve(state)
+			require.NoError(t, err, state.String())
+			require.LessOrEqual(t, len(steps), moves, state.String())
+		}
+	}
+}
+
+func TestGenerateScramble(t *testing.T) {
+	g, err := generate.NewGenerator(generate.WithColors(5), generate.WithScramble(30), generate.WithSteps(8, 0), generate.WithSeed(2))
+	require.NoError(t, err)
+
+	pack, err := g.Pack("Scrambled", 5)
+	require.NoError(t, err)
+	require.Len(t, pack.Levels, 5)
+	for _, level := range pack.Levels {
+		require.GreaterOrEqual(t, level.OptimalSteps, 8)
+	}
+}
+
+func TestGenerateNoLevel(t *testing.T) {
+	g, err := generate.NewGenerator(generate.WithColors(2), generate.WithSteps(50, 0), generate.WithMaxAttempts(10))
+	require.NoError(t, err)
+	_, err = g.Generate()
+	require.True(t, errors.Is(err, generate.ErrNoLevel))
+}
+
+func TestGenerateInvalidOptions(t *testing.T) {
+	_, err := generate.NewGenerator(generate.WithCapacity(5))
+	require.True(t, errors.Is(err, watersortpuzzle.ErrUnsupportedCapacity))
+
+	for _, opts := range [][]generate.Option{
+		{generate.WithColors(0)},
+		{generate.WithColors(27)},
+		{generate.WithEmptyFlasks(-1)},
+		{generate.WithSteps(5, 4)},
+		{generate.WithScore(5, 4)},
+		{generate.WithMaxAttempts(0)},
+		{generate.WithScramble(-1)},
+	} {
+		_, err := generate.NewGenerator(opts...)
+		require.Error(t, err)
+	}
+}
@@ -0,0 +1,90 @@
+package generate
+
+import (
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+)
+
+// reversePour moves pieces of the top color from one flask to another,
+// so that the step from To to From is legal and gets the state back.
+type reversePour struct {
+	from   int
+	to     int
+	pieces int
+}
+
+// Scramble returns state made by random reverse pours from the solved state of the generator
+// colors and empty flasks. The state is always solvable, its solution is at most moves long.
+// Positions seen before are avoided, when there is another choice.
+func (g *Generator) Scramble(moves int) watersortpuzzle.State {
+	state := make(watersortpuzzle.State, g.colors+g.emptyFlasks)
+	for i, c := range colorLetters()[:g.colors] {
+		for j := range state[i] {
+			state[i][j] = c
+		}
+	}
+
+	seen := map[string]struct{}{state.EquivalentString(): {}}
+	for i := 0; i < moves; i++ {
+		pours := reversePours(state)
+		if len(pours) == 0 {
+			break
+		}
+
+		var fresh []reversePour
+		for _, pour := range pours {
+			if _, ok := seen[applyReversePour(state, pour).EquivalentString()]; !ok {
+				fresh = append(fresh, pour)
+			}
+		}
+		if len(fresh) != 0 {
+			pours = fresh
+		}
+
+		state = applyReversePour(state, pours[g.rand.Intn(len(pours))])
+		seen[state.EquivalentString()] = struct{}{}
+	}
+	return state
+}
+
+// reversePours returns all reverse pours, for which the forward step is legal:
+// the poured pieces become the whole top tower of destination flask,
+// and they are poured back onto the same color or into the empty flask.
+func reversePours(state watersortpuzzle.State) []reversePour {
+	var pours []reversePour
+	for from := range state {
+		if state[from].IsEmpty() {
+			continue
+		}
+		clr, height := state[from].Top()
+		size := state[from].Size()
+
+		for to := range state {
+			if to == from {
+				continue
+			}
+			if toColor, _ := state[to].Top(); !state[to].IsEmpty() && toColor == clr {
+				continue
+			}
+			for pieces := 1; pieces <= height && pieces <= state[to].Left(); pieces++ {
+				// Pouring back onto another color is illegal.
+				if pieces == height && pieces != size {
+					continue
+				}
+				pours = append(pours, reversePour{from: from, to: to, pieces: pieces})
+			}
+		}
+	}
+	return pours
+}
+
+func applyReversePour(state watersortpuzzle.State, pour reversePour) watersortpuzzle.State {
+	newState := state.Copy()
+	from, to := &newState[pour.from], &newState[pour.to]
+	clr, _ := from.Top()
+	size, toSize := from.Size(), to.Size()
+	for i := 0; i < pour.pieces; i++ {
+		from[size-1-i] = 0
+		to[toSize+i] = clr
+	}
+	return newState
+}
@@ -6,17 +6,25 @@ type distanceHeapElem struct {
 	distance     int
 	realDistance int
 	elem         State
+	// seq is the number of pushes to heap before this element.
+	seq int
 }
 
 type distanceHeap struct {
 	heap        []*distanceHeapElem
 	elemToIndex map[*distanceHeapElem]int
+	pushed      int
+	tieBreaking TieBreaking
 }
 
 var _ heap.Interface = (*distanceHeap)(nil)
 
-func newDistanceHeap() *distanceHeap {
-	return &distanceHeap{heap: make([]*distanceHeapElem, 0), elemToIndex: make(map[*distanceHeapElem]int)}
+func newDistanceHeap(tieBreaking TieBreaking) *distanceHeap {
+	return &distanceHeap{
+		heap:        make([]*distanceHeapElem, 0),
+		elemToIndex: make(map[*distanceHeapElem]int),
+		tieBreaking: tieBreaking,
+	}
 }
 
 func (d *distanceHeap) Fix(elem *distanceHeapElem) {
@@ -28,7 +36,20 @@ func (d *distanceHeap) Len() int {
 }
 
 func (d *distanceHeap) Less(i, j int) bool {
-	return d.heap[i].distance < d.heap[j].distance
+	lhs, rhs := d.heap[i], d.heap[j]
+	if lhs.distance != rhs.distance {
+		return lhs.distance < rhs.distance
+	}
+
+	switch d.tieBreaking {
+	case TieBreakLargerG:
+		if lhs.realDistance != rhs.realDistance {
+			return lhs.realDistance > rhs.realDistance
+		}
+	case TieBreakLIFO:
+		return lhs.seq > rhs.seq
+	}
+	return lhs.seq < rhs.seq
 }
 
 func (d *distanceHeap) Swap(i, j int) {
@@ -39,6 +60,8 @@ func (d *distanceHeap) Swap(i, j int) {
 
 func (d *distanceHeap) Push(x interface{}) {
 	newElem := x.(*distanceHeapElem)
+	newElem.seq = d.pushed
+	d.pushed++
 	d.elemToIndex[newElem] = len(d.heap)
 	d.heap = append(d.heap, newElem)
 }
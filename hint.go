@@ -0,0 +1,108 @@
+package watersortpuzzle
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrSolved is returned, when hint is asked for a terminal state.
+var ErrSolved = errors.New("puzzle is already solved")
+
+// Hinter suggests moves of optimal solutions.
+// It remembers positions of all solutions it found and positions found lost, so hints for them
+// (in any order of flasks) are answered without a new search. Only those positions are reused:
+// any other position, e.g. after a move off the found solutions, needs a new search.
+type Hinter struct {
+	solver Solver
+
+	// known holds positions of found solutions by EquivalentString.
+	known map[string]hintEntry
+	// lost holds EquivalentString of positions, which can't be solved.
+	lost map[string]struct{}
+}
+
+type hintEntry struct {
+	state     State
+	step      Step
+	remaining int
+}
+
+type HinterOption func(hinter *Hinter)
+
+// HinterWithSolver sets solver used to find solutions. It must be optimal to give optimal hints.
+func HinterWithSolver(solver Solver) HinterOption {
+	return func(hinter *Hinter) {
+		hinter.solver = solver
+	}
+}
+
+func NewHinter(opts ...HinterOption) *Hinter {
+	hinter := &Hinter{
+		solver: NewAStarSolver(),
+		known:  make(map[string]hintEntry),
+		lost:   make(map[string]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(hinter)
+	}
+	return hinter
+}
+
+// Hint returns the first step of an optimal continuation from state and number of steps
+// remaining to solve the puzzle including this one. ErrNotExist means the position is lost.
+func (h *Hinter) Hint(state State) (step Step, remaining int, err error) {
+	if state.IsTerminal() {
+		return Step{}, 0, ErrSolved
+	}
+	if state.IsDeadEnd() {
+		return Step{}, 0, ErrNotExist
+	}
+
+	key := state.EquivalentString()
+	if _, ok := h.lost[key]; ok {
+		return Step{}, 0, ErrNotExist
+	}
+	if entry, ok := h.known[key]; ok {
+		perm, err := Permutation(entry.state, state)
+		if err != nil {
+			return Step{}, 0, err
+		}
+		return RemapSteps([]Step{entry.step}, perm)[0], entry.remaining, nil
+	}
+
+	steps, err := h.solver.Solve(state)
+	if errors.Is(err, ErrNotExist) {
+		h.lost[key] = struct{}{}
+	}
+	if err != nil {
+		return Step{}, 0, err
+	}
+	if err := h.remember(state, steps); err != nil {
+		return Step{}, 0, err
+	}
+	return steps[0], len(steps), nil
+}
+
+// remember adds positions of optimal solution to known ones.
+func (h *Hinter) remember(state State, steps []Step) error {
+	entries := make(map[string]hintEntry, len(steps))
+	for i, step := range steps {
+		entries[state.EquivalentString()] = hintEntry{state: state, step: step, remaining: len(steps) - i}
+
+		var err error
+		if state, err = state.Step(step); err != nil {
+			return fmt.Errorf("solver returned invalid solution: %w", err)
+		}
+	}
+	for key, entry := range entries {
+		h.known[key] = entry
+	}
+	return nil
+}
+
+// Hint returns the first step of an optimal continuation from state using A*.
+// See Hinter.Hint for details.
+func Hint(state State) (step Step, remaining int, err error) {
+	return NewHinter().Hint(state)
+}
@@ -0,0 +1,85 @@
+package watersortpuzzle_test
+
+import (
+	"testing"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/solvertest"
+	"github.com/stretchr/testify/require"
+)
+
+type countingSolver struct {
+	watersortpuzzle.Solver
+	solves int
+}
+
+func (s *countingSolver) Solve(initialState watersortpuzzle.State) ([]watersortpuzzle.Step, error) {
+	s.solves++
+	return s.Solver.Solve(initialState)
+}
+
+func TestHinter(t *testing.T) {
+	state := solvertest.MustState(t, "FORF;OORF;RFOR;;")
+
+	solver := &countingSolver{Solver: watersortpuzzle.NewAStarSolver()}
+	hinter := watersortpuzzle.NewHinter(watersortpuzzle.HinterWithSolver(solver))
+
+	for expectedRemaining := 10; expectedRemaining > 0; expectedRemaining-- {
+		step, remaining, err := hinter.Hint(state)
+		require.NoError(t, err)
+		require.Equal(t, expectedRemaining, remaining)
+
+		state, err = state.Step(step)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 1, solver.solves)
+
+	_, _, err := hinter.Hint(state)
+	require.ErrorIs(t, err, watersortpuzzle.ErrSolved)
+}
+
+func TestHintPermutedPosition(t *testing.T) {
+	state := solvertest.MustState(t, "FORF;OORF;RFOR;;")
+	permutedState := solvertest.MustState(t, ";RFOR;OORF;;FORF")
+
+	solver := &countingSolver{Solver: watersortpuzzle.NewAStarSolver()}
+	hinter := watersortpuzzle.NewHinter(watersortpuzzle.HinterWithSolver(solver))
+
+	_, _, err := hinter.Hint(state)
+	require.NoError(t, err)
+
+	step, remaining, err := hinter.Hint(permutedState)
+	require.NoError(t, err)
+	require.Equal(t, 10, remaining)
+	_, err = permutedState.Step(step)
+	require.NoError(t, err)
+	require.Equal(t, 1, solver.solves)
+}
+
+func TestHinterRemembersAllSolutions(t *testing.T) {
+	state := solvertest.MustState(t, "FORF;OORF;RFOR;;")
+	otherState := solvertest.MustState(t, "GORO;FFRO;PPFO;GPRF;GRGP;;")
+
+	solver := &countingSolver{Solver: watersortpuzzle.NewAStarSolver()}
+	hinter := watersortpuzzle.NewHinter(watersortpuzzle.HinterWithSolver(solver))
+
+	for _, s := range []watersortpuzzle.State{state, otherState, state, otherState} {
+		_, _, err := hinter.Hint(s)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, solver.solves)
+
+	lostState := solvertest.MustState(t, "BCBA;BBAC;CAAC;")
+	for i := 0; i < 2; i++ {
+		_, _, err := hinter.Hint(lostState)
+		require.ErrorIs(t, err, watersortpuzzle.ErrNotExist)
+	}
+	require.Equal(t, 3, solver.solves)
+}
+
+func TestHintLostPosition(t *testing.T) {
+	state := solvertest.MustState(t, "GOFP;GOOB;")
+
+	_, _, err := watersortpuzzle.Hint(state)
+	require.ErrorIs(t, err, watersortpuzzle.ErrNotExist)
+}
@@ -0,0 +1,160 @@
+package watersortpuzzle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrUnsupportedCapacity is returned for puzzles with flasks of capacity other than FlaskCapacity.
+var ErrUnsupportedCapacity = errors.New("unsupported flask capacity")
+
+// Puzzle is JSON description of a level:
+//
+//	{
+//	  "flasks": ["GOFP", "GOOB", ""],
+//	  "capacity": 4,
+//	  "palette": {"G": "#789610", "O": "#f28c28"},
+//	  "metadata": {"level": 42}
+//	}
+//
+// Flasks are written from the bottom. Capacity and metadata are optional,
+// palette gives colors to letters for pictures.
+type Puzzle struct {
+	Flasks   State                  `json:"flasks"`
+	Capacity int                    `json:"capacity,omitempty"`
+	Palette  Palette                `json:"palette,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Result is JSON description of solution:
+//
+//	{"solvable": true, "steps": [{"from": 0, "to": 2}], "length": 1, "optimal": true, "stats": {"steps": 2}}
+//
+// Flasks in steps are numbered from 0, unlike in the program output.
+type Result struct {
+	Solvable bool   `json:"solvable"`
+	Steps    []Step `json:"steps"`
+	Length   int    `json:"length"`
+	// Optimal is true, when there is no shorter solution.
+	Optimal bool   `json:"optimal"`
+	Stats   *Stats `json:"stats,omitempty"`
+}
+
+type puzzleJSON Puzzle
+
+func (p *Puzzle) UnmarshalJSON(data []byte) error {
+	// Capacity goes first, otherwise flasks of other capacity give confusing errors.
+	var capacity struct {
+		Capacity int `json:"capacity"`
+	}
+	if err := json.Unmarshal(data, &capacity); err != nil {
+		return err
+	}
+	if capacity.Capacity != 0 && capacity.Capacity != FlaskCapacity {
+		return fmt.Errorf("%w %d, only %d is supported", ErrUnsupportedCapacity, capacity.Capacity, FlaskCapacity)
+	}
+
+	var puzzle puzzleJSON
+	if err := json.Unmarshal(data, &puzzle); err != nil {
+		return err
+	}
+	if puzzle.Flasks == nil {
+		return errors.New("puzzle has no flasks")
+	}
+	puzzle.Capacity = FlaskCapacity
+	*p = Puzzle(puzzle)
+	return nil
+}
+
+// MarshalJSON writes flask as string of colors from the bottom.
+func (f Flask) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.String())
+}
+
+func (f *Flask) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	var flask Flask
+	if err := flask.FromString(s); err != nil {
+		return err
+	}
+	*f = flask
+	return nil
+}
+
+// MarshalJSON writes state as array of flasks.
+func (s State) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]Flask(s))
+}
+
+// UnmarshalJSON reads state from array of flasks or from String representation.
+func (s *State) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		return s.FromString(str)
+	}
+
+	var flasks []Flask
+	if err := json.Unmarshal(data, &flasks); err != nil {
+		return fmt.Errorf("state must be array of flasks or string: %w", err)
+	}
+	*s = flasks
+	return nil
+}
+
+type stepJSON struct {
+	From *int `json:"from"`
+	To   *int `json:"to"`
+}
+
+// MarshalJSON writes step as {"from": 0, "to": 1}, flasks are numbered from 0.
+func (s Step) MarshalJSON() ([]byte, error) {
+	return json.Marshal(stepJSON{From: &s.From, To: &s.To})
+}
+
+func (s *Step) UnmarshalJSON(data []byte) error {
+	var step stepJSON
+	if err := json.Unmarshal(data, &step); err != nil {
+		return err
+	}
+	if step.From == nil || step.To == nil {
+		return errors.New("step must have both from and to")
+	}
+	if *step.From < 0 || *step.To < 0 {
+		return fmt.Errorf("invalid step %d -> %d: flasks are numbered from 0", *step.From, *step.To)
+	}
+	*s = Step{From: *step.From, To: *step.To}
+	return nil
+}
+
+// MarshalJSON writes palette as {"R": "#ff0000"}.
+func (p Palette) MarshalJSON() ([]byte, error) {
+	entries := make(map[string]string, len(p))
+	for c, rgba := range p {
+		entries[string(c)] = fmt.Sprintf("#%02x%02x%02x", rgba.R, rgba.G, rgba.B)
+	}
+	return json.Marshal(entries)
+}
+
+func (p *Palette) UnmarshalJSON(data []byte) error {
+	var entries map[string]string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	palette := make(Palette, len(entries))
+	for letter, hex := range entries {
+		entry, err := ParsePalette(letter + "=" + hex)
+		if err != nil {
+			return err
+		}
+		for c, rgba := range entry {
+			palette[c] = rgba
+		}
+	}
+	*p = palette
+	return nil
+}
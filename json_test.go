@@ -0,0 +1,74 @@
+package watersortpuzzle_test
+
+import (
+	"encoding/json"
+	"image/color"
+	"testing"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPuzzleJSON(t *testing.T) {
+	data := `{
+		"flasks": ["GOFP", "GOOB", ""],
+		"capacity": 4,
+		"palette": {"G": "#789610"},
+		"metadata": {"level": 42, "name": "first"}
+	}`
+	var puzzle watersortpuzzle.Puzzle
+	require.NoError(t, json.Unmarshal([]byte(data), &puzzle))
+	require.Equal(t, "GOFP;GOOB;", puzzle.Flasks.String())
+	require.Equal(t, watersortpuzzle.FlaskCapacity, puzzle.Capacity)
+	require.Equal(t, color.RGBA{R: 0x78, G: 0x96, B: 0x10, A: 0xff}, puzzle.Palette['G'])
+	require.Equal(t, map[string]interface{}{"level": 42.0, "name": "first"}, puzzle.Metadata)
+
+	encoded, err := json.Marshal(puzzle)
+	require.NoError(t, err)
+	require.JSONEq(t, data, string(encoded))
+
+	// Capacity is optional, and state may be given as string.
+	require.NoError(t, json.Unmarshal([]byte(`{"flasks": "GOFP;GOOB;"}`), &puzzle))
+	require.Equal(t, "GOFP;GOOB;", puzzle.Flasks.String())
+	require.Equal(t, watersortpuzzle.FlaskCapacity, puzzle.Capacity)
+
+	err = json.Unmarshal([]byte(`{"flasks": ["GOFPG"], "capacity": 5}`), &puzzle)
+	require.ErrorIs(t, err, watersortpuzzle.ErrUnsupportedCapacity)
+	for _, data := range []string{`{}`, `{"flasks": ["GOFPG"]}`, `{"flasks": [1]}`, `{"flasks": ["G"], "palette": {"G": "green"}}`} {
+		require.Error(t, json.Unmarshal([]byte(data), &puzzle), data)
+	}
+}
+
+func TestStepJSON(t *testing.T) {
+	encoded, err := json.Marshal([]watersortpuzzle.Step{{From: 0, To: 2}})
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"from": 0, "to": 2}]`, string(encoded))
+
+	var steps []watersortpuzzle.Step
+	// Fields are case-insensitive, so files written before are read too.
+	require.NoError(t, json.Unmarshal([]byte(`[{"from": 1, "to": 0}, {"From": 2, "To": 3}]`), &steps))
+	require.Equal(t, []watersortpuzzle.Step{{From: 1, To: 0}, {From: 2, To: 3}}, steps)
+
+	for _, data := range []string{`[{"from": 1}]`, `[{"from": -1, "to": 0}]`, `[[1, 2]]`} {
+		require.Error(t, json.Unmarshal([]byte(data), &steps), data)
+	}
+}
+
+func TestResultJSON(t *testing.T) {
+	result := watersortpuzzle.Result{
+		Solvable: true,
+		Steps:    []watersortpuzzle.Step{{From: 0, To: 1}},
+		Length:   1,
+		Optimal:  true,
+		Stats:    &watersortpuzzle.Stats{Steps: 2},
+	}
+	encoded, err := json.Marshal(result)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"solvable": true,
+		"steps": [{"from": 0, "to": 1}],
+		"length": 1,
+		"optimal": true,
+		"stats": {"steps": 2, "transposition_hits": 0, "transposition_cutoffs": 0}
+	}`, string(encoded))
+}
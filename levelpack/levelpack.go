@@ -0,0 +1,160 @@
+// Package levelpack reads and writes collections of levels in JSON:
+//
+//	{
+//	  "name": "Classic",
+//	  "levels": [
+//	    {"number": 1, "name": "First", "state": "O;OOO", "optimal_steps": 1, "tags": ["tutorial"]}
+//	  ]
+//	}
+//
+// State is written as String of watersortpuzzle.State, an array of flasks is read too.
+// Optimal steps, name and tags are optional. Zero optimal steps means the length is unknown.
+package levelpack
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+)
+
+// ErrInvalidPack is returned for packs, which are read, but don't make sense.
+var ErrInvalidPack = errors.New("invalid level pack")
+
+type Pack struct {
+	Name   string  `json:"name,omitempty"`
+	Levels []Level `json:"levels"`
+}
+
+type Level struct {
+	// Number is unique in pack. Levels go in order of numbers in game.
+	Number int                   `json:"number"`
+	Name   string                `json:"name,omitempty"`
+	State  watersortpuzzle.State `json:"state"`
+	// OptimalSteps is the length of the shortest solution, 0 if it isn't known.
+	OptimalSteps int      `json:"optimal_steps,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+}
+
+type levelJSON struct {
+	Number       int             `json:"number"`
+	Name         string          `json:"name,omitempty"`
+	State        json.RawMessage `json:"state"`
+	OptimalSteps int             `json:"optimal_steps,omitempty"`
+	Tags         []string        `json:"tags,omitempty"`
+}
+
+// MarshalJSON writes state as String, which is easier to read in pack.
+func (l Level) MarshalJSON() ([]byte, error) {
+	state, err := json.Marshal(l.State.String())
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(levelJSON{
+		Number:       l.Number,
+		Name:         l.Name,
+		State:        state,
+		OptimalSteps: l.OptimalSteps,
+		Tags:         l.Tags,
+	})
+}
+
+// HasTag reports whether level is tagged with tag.
+func (l *Level) HasTag(tag string) bool {
+	for _, t := range l.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Read reads pack from r and validates it.
+func Read(r io.Reader) (*Pack, error) {
+	var pack Pack
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&pack); err != nil {
+		return nil, fmt.Errorf("cannot read level pack: %w", err)
+	}
+	if err := pack.Validate(); err != nil {
+		return nil, err
+	}
+	return &pack, nil
+}
+
+// Load reads pack from file.
+func Load(path string) (*Pack, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open level pack: %w", err)
+	}
+	defer file.Close()
+	return Read(bufio.NewReader(file))
+}
+
+// Write validates pack and writes it to w with one level per line.
+func Write(w io.Writer, pack *Pack) error {
+	if err := pack.Validate(); err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(w)
+	name, err := json.Marshal(pack.Name)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "{\n  \"name\": %s,\n  \"levels\": [\n", name)
+	for i, level := range pack.Levels {
+		data, err := json.Marshal(level)
+		if err != nil {
+			return fmt.Errorf("cannot write level %d: %w", level.Number, err)
+		}
+		separator := ","
+		if i == len(pack.Levels)-1 {
+			separator = ""
+		}
+		fmt.Fprintf(out, "    %s%s\n", data, separator)
+	}
+	fmt.Fprint(out, "  ]\n}\n")
+	return out.Flush()
+}
+
+// Save writes pack to file.
+func Save(path string, pack *Pack) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("cannot create level pack: %w", err)
+	}
+	if err := Write(file, pack); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Validate checks that level numbers are unique and positive, and every level has a state.
+func (p *Pack) Validate() error {
+	numbers := make(map[int]struct{}, len(p.Levels))
+	for _, level := range p.Levels {
+		if level.Number <= 0 {
+			return fmt.Errorf("%w: level number %d must be positive", ErrInvalidPack, level.Number)
+		}
+		if _, ok := numbers[level.Number]; ok {
+			return fmt.Errorf("%w: level number %d is repeated", ErrInvalidPack, level.Number)
+		}
+		numbers[level.Number] = struct{}{}
+
+		if len(level.State) == 0 {
+			return fmt.Errorf("%w: level %d has no flasks", ErrInvalidPack, level.Number)
+		}
+		if level.OptimalSteps < 0 {
+			return fmt.Errorf("%w: level %d has negative optimal steps", ErrInvalidPack, level.Number)
+		}
+	}
+	return nil
+}
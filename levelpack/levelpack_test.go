@@ -0,0 +1,72 @@
+package levelpack_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkositsyn/water-sort-puzzle-solver/levelpack"
+	"github.com/pkositsyn/water-sort-puzzle-solver/solvertest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackRoundTrip(t *testing.T) {
+	pack := &levelpack.Pack{
+		Name: "Test",
+		Levels: []levelpack.Level{
+			{Number: 1, Name: "First", State: solvertest.MustState(t, "O;OOO"), OptimalSteps: 1, Tags: []string{"tutorial"}},
+			{Number: 2, State: solvertest.MustState(t, "FORF;OORF;RFOR;;")},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, levelpack.Write(&buf, pack))
+	require.Equal(t, `{
+  "name": "Test",
+  "levels": [
+    {"number":1,"name":"First","state":"O;OOO","optimal_steps":1,"tags":["tutorial"]},
+    {"number":2,"state":"FORF;OORF;RFOR;;"}
+  ]
+}
+`, buf.String())
+
+	read, err := levelpack.Read(&buf)
+	require.NoError(t, err)
+	require.Equal(t, pack, read)
+	require.True(t, read.Levels[0].HasTag("tutorial"))
+	require.False(t, read.Levels[1].HasTag("tutorial"))
+
+	path := filepath.Join(t.TempDir(), "pack.json")
+	require.NoError(t, levelpack.Save(path, pack))
+	loaded, err := levelpack.Load(path)
+	require.NoError(t, err)
+	require.Equal(t, pack, loaded)
+}
+
+func TestPackStateAsFlasks(t *testing.T) {
+	pack, err := levelpack.Read(strings.NewReader(`{"levels": [{"number": 1, "state": ["O", "OOO"]}]}`))
+	require.NoError(t, err)
+	require.Equal(t, "O;OOO", pack.Levels[0].State.String())
+}
+
+func TestPackInvalid(t *testing.T) {
+	for _, data := range []string{
+		`{"levels": [{"number": 0, "state": "O;OOO"}]}`,
+		`{"levels": [{"number": 1, "state": "O;OOO"}, {"number": 1, "state": "OO;OO"}]}`,
+		`{"levels": [{"number": 1}]}`,
+		`{"levels": [{"number": 1, "state": "O;OOO", "optimal_steps": -1}]}`,
+	} {
+		_, err := levelpack.Read(strings.NewReader(data))
+		require.ErrorIs(t, err, levelpack.ErrInvalidPack, data)
+	}
+
+	for _, data := range []string{
+		`{"levels": [{"number": 1, "state": "OOOOO"}]}`,
+		`{"levels": [{"number": 1, "state": "O;OOO", "optimal": 1}]}`,
+		`[]`,
+	} {
+		_, err := levelpack.Read(strings.NewReader(data))
+		require.Error(t, err, data)
+	}
+}
@@ -0,0 +1,113 @@
+package watersortpuzzle
+
+import (
+	"fmt"
+	"hash/fnv"
+	"image/color"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Palette maps colors of the puzzle to colors for drawing it.
+type Palette map[Color]color.RGBA
+
+// DefaultPalette names colors by the first letter of their name, like in README.
+// Dark blue is F, as in the README example.
+var DefaultPalette = Palette{
+	'A': {R: 0x80, G: 0x80, B: 0x80, A: 0xff}, // ash gray
+	'B': {R: 0x3d, G: 0x9b, B: 0xe9, A: 0xff}, // blue
+	'C': {R: 0x4d, G: 0xd8, B: 0xe0, A: 0xff}, // cyan
+	'F': {R: 0x2a, G: 0x3a, B: 0xb0, A: 0xff}, // dark blue
+	'G': {R: 0x2e, G: 0x8b, B: 0x3e, A: 0xff}, // green
+	'L': {R: 0x9c, G: 0xde, B: 0x4a, A: 0xff}, // lime
+	'N': {R: 0x7b, G: 0x4a, B: 0x1e, A: 0xff}, // brown
+	'O': {R: 0xf2, G: 0x8c, B: 0x28, A: 0xff}, // orange
+	'P': {R: 0xea, G: 0x6f, B: 0xb5, A: 0xff}, // pink
+	'R': {R: 0xd6, G: 0x2b, B: 0x2b, A: 0xff}, // red
+	'V': {R: 0x7e, G: 0x3f, B: 0xb8, A: 0xff}, // violet
+	'Y': {R: 0xf5, G: 0xd3, B: 0x2f, A: 0xff}, // yellow
+}
+
+// ParsePalette parses palette in form "R=#ff0000,G=#00ff00".
+func ParsePalette(s string) (Palette, error) {
+	palette := make(Palette)
+	if s == "" {
+		return palette, nil
+	}
+
+	for _, entry := range strings.Split(s, ",") {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid palette entry %q: expected <letter>=#rrggbb", entry)
+		}
+
+		r, size := utf8.DecodeRuneInString(parts[0])
+		if r == utf8.RuneError || size != len(parts[0]) {
+			return nil, fmt.Errorf("invalid palette entry %q: expected one letter before '='", entry)
+		}
+		if clr := Color(r); clr == invalidColor || clr == colorNone {
+			return nil, fmt.Errorf("invalid palette entry %q: invalid color", entry)
+		}
+
+		rgba, err := parseHexColor(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid palette entry %q: %w", entry, err)
+		}
+		palette[Color(r)] = rgba
+	}
+	return palette, nil
+}
+
+func parseHexColor(s string) (color.RGBA, error) {
+	if len(s) != 7 || s[0] != '#' {
+		return color.RGBA{}, fmt.Errorf("expected color as #rrggbb, got %q", s)
+	}
+	value, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("expected color as #rrggbb, got %q", s)
+	}
+	return color.RGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 0xff}, nil
+}
+
+// String formats palette the way ParsePalette reads it, ordered by colors.
+func (p Palette) String() string {
+	colors := make([]Color, 0, len(p))
+	for c := range p {
+		colors = append(colors, c)
+	}
+	sort.Slice(colors, func(i, j int) bool { return colors[i] < colors[j] })
+
+	entries := make([]string, 0, len(colors))
+	for _, c := range colors {
+		rgba := p[c]
+		entries = append(entries, fmt.Sprintf("%c=#%02x%02x%02x", c, rgba.R, rgba.G, rgba.B))
+	}
+	return strings.Join(entries, ",")
+}
+
+// RGBA returns color to draw c with. Colors missing in palette are taken from DefaultPalette,
+// and colors missing there get a fallback color derived from the letter, so they stay distinct.
+func (p Palette) RGBA(c Color) color.RGBA {
+	if rgba, ok := p[c]; ok {
+		return rgba
+	}
+	if rgba, ok := DefaultPalette[c]; ok {
+		return rgba
+	}
+	return fallbackColor(c)
+}
+
+// fallbackColor picks bright enough color by hash of c.
+func fallbackColor(c Color) color.RGBA {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(string(rune(c))))
+	sum := hash.Sum32()
+	return color.RGBA{
+		R: 0x40 + uint8(sum)%0xa0,
+		G: 0x40 + uint8(sum>>8)%0xa0,
+		B: 0x40 + uint8(sum>>16)%0xa0,
+		A: 0xff,
+	}
+}
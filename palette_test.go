@@ -0,0 +1,34 @@
+package watersortpuzzle_test
+
+import (
+	"image/color"
+	"testing"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePalette(t *testing.T) {
+	palette, err := watersortpuzzle.ParsePalette("R=#ff0000,Q=#0a0B0c")
+	require.NoError(t, err)
+	require.Equal(t, watersortpuzzle.Palette{
+		'R': {R: 0xff, A: 0xff},
+		'Q': {R: 0x0a, G: 0x0b, B: 0x0c, A: 0xff},
+	}, palette)
+	require.Equal(t, "Q=#0a0b0c,R=#ff0000", palette.String())
+
+	for _, s := range []string{"R", "RR=#ff0000", "R=ff0000", "R=#ff00", "R=#gg0000", ";=#ff0000", "R=#ff0000,"} {
+		_, err := watersortpuzzle.ParsePalette(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestPaletteRGBA(t *testing.T) {
+	palette := watersortpuzzle.Palette{'R': {R: 0xff, A: 0xff}}
+	require.Equal(t, color.RGBA{R: 0xff, A: 0xff}, palette.RGBA('R'))
+	require.Equal(t, watersortpuzzle.DefaultPalette['G'], palette.RGBA('G'))
+
+	// Unknown colors get stable and distinct fallback colors.
+	require.Equal(t, palette.RGBA('Q'), palette.RGBA('Q'))
+	require.NotEqual(t, palette.RGBA('Q'), palette.RGBA('T'))
+}
@@ -0,0 +1,47 @@
+package watersortpuzzle
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotEquivalent is returned, when states differ not only in order of flasks.
+var ErrNotEquivalent = errors.New("states are not equivalent")
+
+// Permutation returns mapping of flasks between two equivalent states:
+// flask i of from is the same as flask perm[i] of to.
+// Equal flasks are matched in order of their indexes.
+func Permutation(from, to State) ([]int, error) {
+	if len(from) != len(to) {
+		return nil, fmt.Errorf("%w: %d and %d flasks", ErrNotEquivalent, len(from), len(to))
+	}
+
+	indexesByFlask := make(map[Flask][]int)
+	for i, f := range to {
+		indexesByFlask[f] = append(indexesByFlask[f], i)
+	}
+
+	perm := make([]int, len(from))
+	for i, f := range from {
+		indexes := indexesByFlask[f]
+		if len(indexes) == 0 {
+			return nil, fmt.Errorf("%w: flask %q has no pair", ErrNotEquivalent, f.String())
+		}
+		perm[i] = indexes[0]
+		indexesByFlask[f] = indexes[1:]
+	}
+	return perm, nil
+}
+
+// RemapSteps converts steps to another order of flasks: flask i becomes perm[i].
+// With perm from Permutation(from, to) steps solving from become steps solving to.
+func RemapSteps(steps []Step, perm []int) []Step {
+	if steps == nil {
+		return nil
+	}
+	remapped := make([]Step, 0, len(steps))
+	for _, step := range steps {
+		remapped = append(remapped, Step{From: perm[step.From], To: perm[step.To]})
+	}
+	return remapped
+}
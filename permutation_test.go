@@ -0,0 +1,34 @@
+package watersortpuzzle_test
+
+import (
+	"testing"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/solvertest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermutation(t *testing.T) {
+	from := solvertest.MustState(t, "FORF;OORF;RFOR;;")
+	to := solvertest.MustState(t, ";RFOR;;FORF;OORF")
+
+	perm, err := watersortpuzzle.Permutation(from, to)
+	require.NoError(t, err)
+	require.Equal(t, []int{3, 4, 1, 0, 2}, perm)
+
+	steps, err := watersortpuzzle.NewAStarSolver().Solve(from)
+	require.NoError(t, err)
+	requireSolves(t, to, watersortpuzzle.RemapSteps(steps, perm))
+}
+
+func TestPermutationNotEquivalent(t *testing.T) {
+	from := solvertest.MustState(t, "FORF;OORF;RFOR;;")
+	to := solvertest.MustState(t, "FORF;OORF;RFRO;;")
+	shorter := solvertest.MustState(t, "FORF;OORF;RFOR;")
+
+	_, err := watersortpuzzle.Permutation(from, to)
+	require.ErrorIs(t, err, watersortpuzzle.ErrNotEquivalent)
+
+	_, err = watersortpuzzle.Permutation(from, shorter)
+	require.ErrorIs(t, err, watersortpuzzle.ErrNotEquivalent)
+}
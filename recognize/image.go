@@ -0,0 +1,292 @@
+package recognize
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+type rgb [3]float64
+
+func rgbOf(c color.RGBA) rgb {
+	return rgb{float64(c.R), float64(c.G), float64(c.B)}
+}
+
+func (c rgb) rgba() color.RGBA {
+	return color.RGBA{R: uint8(math.Round(c[0])), G: uint8(math.Round(c[1])), B: uint8(math.Round(c[2])), A: 0xff}
+}
+
+func (c rgb) hex() string {
+	rgba := c.rgba()
+	return fmt.Sprintf("%02x%02x%02x", rgba.R, rgba.G, rgba.B)
+}
+
+func distance(a, b rgb) float64 {
+	return math.Sqrt((a[0]-b[0])*(a[0]-b[0]) + (a[1]-b[1])*(a[1]-b[1]) + (a[2]-b[2])*(a[2]-b[2]))
+}
+
+func pixel(img *image.RGBA, x, y int) rgb {
+	return rgbOf(img.RGBAAt(x, y))
+}
+
+// dominantColor returns average of colors in the most common bucket of coarsely quantized colors.
+func dominantColor(colors []rgb) rgb {
+	type bucket struct {
+		sum   rgb
+		count int
+	}
+	buckets := make(map[[3]int]*bucket)
+	var best *bucket
+	for _, c := range colors {
+		key := [3]int{int(c[0]) >> 4, int(c[1]) >> 4, int(c[2]) >> 4}
+		b, ok := buckets[key]
+		if !ok {
+			b = &bucket{}
+			buckets[key] = b
+		}
+		for i := range c {
+			b.sum[i] += c[i]
+		}
+		b.count++
+		if best == nil || b.count > best.count {
+			best = b
+		}
+	}
+	if best == nil {
+		return rgb{}
+	}
+	return rgb{best.sum[0] / float64(best.count), best.sum[1] / float64(best.count), best.sum[2] / float64(best.count)}
+}
+
+// estimateBackground returns the dominant color of image border.
+func estimateBackground(img *image.RGBA) rgb {
+	bounds := img.Bounds()
+	var colors []rgb
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		colors = append(colors, pixel(img, x, bounds.Min.Y), pixel(img, x, bounds.Max.Y-1))
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		colors = append(colors, pixel(img, bounds.Min.X, y), pixel(img, bounds.Max.X-1, y))
+	}
+	return dominantColor(colors)
+}
+
+// findFlasks returns bounds of flasks ordered by rows from top, and from left in a row.
+// Flasks are tall connected components of pixels, which differ from background.
+func findFlasks(img *image.RGBA, background rgb) []image.Rectangle {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	foreground := make([]bool, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			foreground[y*width+x] = distance(pixel(img, bounds.Min.X+x, bounds.Min.Y+y), background) > foregroundDistance
+		}
+	}
+
+	var components []image.Rectangle
+	visited := make([]bool, width*height)
+	var stack []int
+	for start := range foreground {
+		if !foreground[start] || visited[start] {
+			continue
+		}
+
+		component := image.Rect(start%width, start/width, start%width+1, start/width+1)
+		visited[start] = true
+		stack = append(stack[:0], start)
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			x, y := i%width, i/width
+			component = component.Union(image.Rect(x, y, x+1, y+1))
+
+			for _, n := range [4][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+				if n[0] < 0 || n[0] >= width || n[1] < 0 || n[1] >= height {
+					continue
+				}
+				if j := n[1]*width + n[0]; foreground[j] && !visited[j] {
+					visited[j] = true
+					stack = append(stack, j)
+				}
+			}
+		}
+		components = append(components, component.Add(bounds.Min))
+	}
+
+	var maxHeight int
+	for _, c := range components {
+		if c.Dy() > maxHeight {
+			maxHeight = c.Dy()
+		}
+	}
+	var tall []image.Rectangle
+	for _, c := range components {
+		if c.Dy()*2 >= maxHeight && c.Dy()*2 >= c.Dx()*3 && c.Dx() >= 3 {
+			tall = append(tall, c)
+		}
+	}
+
+	// Pieces, which don't touch walls, form components inside flasks.
+	var flasks []image.Rectangle
+	for i, c := range tall {
+		inner := false
+		for j, other := range tall {
+			if i != j && c.In(other) && c != other {
+				inner = true
+				break
+			}
+		}
+		if !inner {
+			flasks = append(flasks, c)
+		}
+	}
+	sortByRows(flasks)
+	return flasks
+}
+
+func sortByRows(flasks []image.Rectangle) {
+	sort.Slice(flasks, func(i, j int) bool { return flasks[i].Min.Y < flasks[j].Min.Y })
+	row := make([]int, len(flasks))
+	for i := 1; i < len(flasks); i++ {
+		row[i] = row[i-1]
+		if flasks[i].Min.Y-flasks[i-1].Min.Y > flasks[i-1].Dy()/2 {
+			row[i]++
+		}
+	}
+	rowOf := make(map[image.Rectangle]int, len(flasks))
+	for i, f := range flasks {
+		rowOf[f] = row[i]
+	}
+	sort.SliceStable(flasks, func(i, j int) bool {
+		if rowOf[flasks[i]] != rowOf[flasks[j]] {
+			return rowOf[flasks[i]] < rowOf[flasks[j]]
+		}
+		return flasks[i].Min.X < flasks[j].Min.X
+	})
+}
+
+// estimateWall returns color of the left wall of flask.
+func estimateWall(img *image.RGBA, flask image.Rectangle, background rgb) rgb {
+	var colors []rgb
+	for y := flask.Min.Y + flask.Dy()/4; y < flask.Max.Y-flask.Dy()/4; y++ {
+		for x := flask.Min.X; x < flask.Min.X+flask.Dx()/2; x++ {
+			if distance(pixel(img, x, y), background) <= foregroundDistance {
+				continue
+			}
+			// The first pixel may be blended with background.
+			if inner := pixel(img, x+1, y); distance(inner, background) > foregroundDistance {
+				colors = append(colors, inner)
+			}
+			break
+		}
+	}
+	return dominantColor(colors)
+}
+
+// sampleFlask returns colored samples along the center of flask from the top,
+// and height of flask space inside walls.
+func sampleFlask(img *image.RGBA, flask image.Rectangle, background, wall rgb) ([]sample, int) {
+	left, right := flask.Min.X+flask.Dx()*3/10, flask.Max.X-flask.Dx()*3/10
+	if right <= left {
+		right = left + 1
+	}
+
+	var samples []sample
+	top, bottom := -1, -1
+	for y := flask.Min.Y; y < flask.Max.Y; y++ {
+		var sum rgb
+		for x := left; x < right; x++ {
+			c := pixel(img, x, y)
+			for i := range sum {
+				sum[i] += c[i]
+			}
+		}
+		n := float64(right - left)
+		c := rgb{sum[0] / n, sum[1] / n, sum[2] / n}
+
+		if distance(c, wall) < classDistance {
+			continue
+		}
+		if top == -1 {
+			top = y
+		}
+		bottom = y
+		if distance(c, background) >= classDistance {
+			samples = append(samples, sample{y: y, color: c})
+		}
+	}
+	if top == -1 {
+		return nil, 0
+	}
+	return samples, bottom - top + 1
+}
+
+// splitRuns splits samples into runs of one color. Short runs of blended colors are dropped.
+func splitRuns(samples []sample, flaskHeight int) []*run {
+	minHeight := flaskHeight / 50
+	if minHeight < 2 {
+		minHeight = 2
+	}
+
+	var runs []*run
+	var current *run
+	var sum rgb
+	flush := func() {
+		if current != nil && current.height >= minHeight {
+			n := float64(current.height)
+			current.mean = rgb{sum[0] / n, sum[1] / n, sum[2] / n}
+			runs = append(runs, current)
+		}
+		current, sum = nil, rgb{}
+	}
+
+	for _, s := range samples {
+		if current != nil {
+			n := float64(current.height)
+			mean := rgb{sum[0] / n, sum[1] / n, sum[2] / n}
+			if s.y != current.top+current.height || distance(mean, s.color) > runDistance {
+				flush()
+			}
+		}
+		if current == nil {
+			current = &run{top: s.y}
+		}
+		current.height++
+		for i := range sum {
+			sum[i] += s.color[i]
+		}
+	}
+	flush()
+	return runs
+}
+
+// estimatePieceHeight finds height of one piece, so heights of runs are close to its multiples.
+// The shortest run is taken first, but flask space is a hint too, when every run has several pieces.
+func estimatePieceHeight(runs []*run, interior int) float64 {
+	guess := float64(interior) / float64(capacity)
+	if guess < 1 {
+		guess = 1
+	}
+	// Too short runs are probably noise.
+	shortest := guess
+	for _, run := range runs {
+		if h := float64(run.height); h < shortest && h*2 >= guess {
+			shortest = h
+		}
+	}
+
+	var heights float64
+	var pieces int
+	for _, run := range runs {
+		if n := int(math.Round(float64(run.height) / shortest)); n > 0 {
+			heights += float64(run.height)
+			pieces += n
+		}
+	}
+	if pieces == 0 {
+		return shortest
+	}
+	return heights / float64(pieces)
+}
@@ -0,0 +1,347 @@
+// Package recognize reads puzzle state from screenshots of the game.
+//
+// Recognition goes in steps:
+//   - background color is the most common color at the image border;
+//   - flasks are tall connected components of pixels, which differ from background;
+//   - colors are sampled along the center line of every flask and split into runs;
+//   - height of one piece is estimated from all runs, and every run becomes a number of pieces;
+//   - colors of runs are clustered, and clusters are named by the closest palette colors.
+package recognize
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+	"strings"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+)
+
+// ErrNoFlasks is returned, when no flasks are found in the image.
+var ErrNoFlasks = errors.New("no flasks found in the image")
+
+const capacity = watersortpuzzle.FlaskCapacity
+
+// Thresholds of Euclidean distance in RGB space, where colors are 0..255.
+const (
+	// foregroundDistance separates flasks from background with its decorations.
+	foregroundDistance = 80
+	// classDistance tells, whether a sample is wall or empty space.
+	classDistance = 60
+	// runDistance splits samples of a flask into runs of different colors.
+	runDistance = 40
+	// clusterDistance joins runs of different flasks into one color.
+	clusterDistance = 50
+	// nameDistance is the maximum distance to palette color to take its letter.
+	nameDistance = 120
+)
+
+// Recognizer finds flasks and their contents in images.
+type Recognizer struct {
+	palette watersortpuzzle.Palette
+}
+
+type Option func(r *Recognizer)
+
+// WithPalette sets colors to name recognized colors by. DefaultPalette colors are used as well.
+func WithPalette(palette watersortpuzzle.Palette) Option {
+	return func(r *Recognizer) {
+		r.palette = palette
+	}
+}
+
+func NewRecognizer(opts ...Option) *Recognizer {
+	r := &Recognizer{}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Result of recognition with details to judge its quality.
+type Result struct {
+	State  watersortpuzzle.State
+	Flasks []FlaskReport
+	Colors []ColorReport
+	// PieceHeight is the estimated height of one piece in pixels.
+	PieceHeight float64
+	// Confidence from 0 to 1. Issues explain, why it is less than 1.
+	Confidence float64
+	Issues     []string
+}
+
+// FlaskReport describes one recognized flask.
+type FlaskReport struct {
+	Bounds image.Rectangle
+	// Runs of colors from the bottom of the flask.
+	Runs []RunReport
+}
+
+// RunReport describes several pieces of the same color in a row.
+type RunReport struct {
+	Color  watersortpuzzle.Color
+	Height int
+	Pieces int
+}
+
+// ColorReport describes one recognized color.
+type ColorReport struct {
+	Color  watersortpuzzle.Color
+	RGBA   color.RGBA
+	Pieces int
+	// PaletteDistance is the distance to palette color of the same letter.
+	// It is negative, when the letter isn't from palette.
+	PaletteDistance float64
+}
+
+// Report is human-readable description of result.
+func (r *Result) Report() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Position: %s\n", r.State)
+	fmt.Fprintf(&b, "Confidence: %.0f%%\n", r.Confidence*100)
+	fmt.Fprintf(&b, "Found %d flasks, piece height %.1fpx\n", len(r.Flasks), r.PieceHeight)
+	for _, c := range r.Colors {
+		fmt.Fprintf(&b, "  %c #%02x%02x%02x %d pieces", c.Color, c.RGBA.R, c.RGBA.G, c.RGBA.B, c.Pieces)
+		if c.PaletteDistance < 0 {
+			b.WriteString(", not in palette")
+		}
+		b.WriteByte('\n')
+	}
+	for _, issue := range r.Issues {
+		fmt.Fprintf(&b, "Warning: %s\n", issue)
+	}
+	return b.String()
+}
+
+// sample is average color of a row in the middle of flask.
+type sample struct {
+	y     int
+	color rgb
+}
+
+type run struct {
+	flask  int
+	top    int
+	height int
+	mean   rgb
+	pieces int
+
+	cluster int
+	name    watersortpuzzle.Color
+}
+
+// Recognize finds state of the puzzle in img.
+func (r *Recognizer) Recognize(img image.Image) (*Result, error) {
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	background := estimateBackground(rgba)
+	flasks := findFlasks(rgba, background)
+	if len(flasks) == 0 {
+		return nil, ErrNoFlasks
+	}
+
+	result := &Result{Confidence: 1}
+	var runs []*run
+	var interior int
+	for i, bounds := range flasks {
+		wall := estimateWall(rgba, bounds, background)
+		samples, flaskInterior := sampleFlask(rgba, bounds, background, wall)
+		for _, run := range splitRuns(samples, bounds.Dy()) {
+			run.flask = i
+			runs = append(runs, run)
+		}
+		if flaskInterior > interior {
+			interior = flaskInterior
+		}
+	}
+
+	result.PieceHeight = estimatePieceHeight(runs, interior)
+	r.countPieces(result, runs)
+	r.nameColors(result, runs)
+	r.buildState(result, flasks, runs)
+	return result, nil
+}
+
+// countPieces converts heights of runs into numbers of pieces. Too short runs are dropped.
+func (r *Recognizer) countPieces(result *Result, runs []*run) {
+	var maxDeviation float64
+	for _, run := range runs {
+		pieces := float64(run.height) / result.PieceHeight
+		run.pieces = int(math.Round(pieces))
+		if run.pieces == 0 {
+			if pieces > 0.25 {
+				result.addIssue(1-pieces, "run of %d pixels in flask %d is too short for a piece", run.height, run.flask+1)
+			}
+			continue
+		}
+		if deviation := math.Abs(pieces - float64(run.pieces)); deviation > maxDeviation {
+			maxDeviation = deviation
+		}
+	}
+	result.Confidence *= 1 - 2*maxDeviation
+}
+
+// nameColors clusters colors of runs and names clusters by the closest palette colors.
+func (r *Recognizer) nameColors(result *Result, runs []*run) {
+	type cluster struct {
+		sum    [3]float64
+		pieces int
+	}
+	var clusters []*cluster
+	mean := func(c *cluster) rgb {
+		return rgb{c.sum[0] / float64(c.pieces), c.sum[1] / float64(c.pieces), c.sum[2] / float64(c.pieces)}
+	}
+
+	for _, run := range runs {
+		if run.pieces == 0 {
+			continue
+		}
+		run.cluster = -1
+		for i, c := range clusters {
+			if distance(mean(c), run.mean) < clusterDistance {
+				run.cluster = i
+				break
+			}
+		}
+		if run.cluster == -1 {
+			run.cluster = len(clusters)
+			clusters = append(clusters, &cluster{})
+		}
+		c := clusters[run.cluster]
+		for i := range c.sum {
+			c.sum[i] += run.mean[i] * float64(run.pieces)
+		}
+		c.pieces += run.pieces
+	}
+
+	// Palette letters are given to the closest clusters first.
+	type candidate struct {
+		cluster  int
+		color    watersortpuzzle.Color
+		distance float64
+	}
+	var candidates []candidate
+	for i, c := range clusters {
+		for _, letter := range r.paletteColors() {
+			d := distance(mean(c), rgbOf(r.palette.RGBA(letter)))
+			if d < nameDistance {
+				candidates = append(candidates, candidate{cluster: i, color: letter, distance: d})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].distance < candidates[j].distance })
+
+	names := make([]watersortpuzzle.Color, len(clusters))
+	distances := make([]float64, len(clusters))
+	used := make(map[watersortpuzzle.Color]bool)
+	for _, c := range candidates {
+		if names[c.cluster] != 0 || used[c.color] {
+			continue
+		}
+		names[c.cluster], distances[c.cluster] = c.color, c.distance
+		used[c.color] = true
+		if c.distance > clusterDistance {
+			result.addIssue(0.9, "color #%s is far from palette color of %c", mean(clusters[c.cluster]).hex(), c.color)
+		}
+	}
+
+	next := watersortpuzzle.Color('A')
+	for i := range clusters {
+		if names[i] != 0 {
+			continue
+		}
+		for used[next] || r.inPalette(next) {
+			next++
+		}
+		names[i], distances[i] = next, -1
+		used[next] = true
+		result.addIssue(0.9, "color #%s is not in palette, named %c", mean(clusters[i]).hex(), next)
+	}
+
+	wrongCounts := false
+	for i, c := range clusters {
+		result.Colors = append(result.Colors, ColorReport{
+			Color:           names[i],
+			RGBA:            mean(c).rgba(),
+			Pieces:          c.pieces,
+			PaletteDistance: distances[i],
+		})
+		if c.pieces%capacity != 0 {
+			wrongCounts = true
+			result.addIssue(1, "found %d pieces of color %c, expected a multiple of %d", c.pieces, names[i], capacity)
+		}
+	}
+	// It may be a part of the level, so it isn't that bad.
+	if wrongCounts {
+		result.Confidence *= 0.5
+	}
+	for _, run := range runs {
+		if run.pieces != 0 {
+			run.name = names[run.cluster]
+		}
+	}
+}
+
+// buildState makes state from runs, which are named by nameColors.
+func (r *Recognizer) buildState(result *Result, flasks []image.Rectangle, runs []*run) {
+	result.State = make(watersortpuzzle.State, len(flasks))
+	result.Flasks = make([]FlaskReport, len(flasks))
+	for i, bounds := range flasks {
+		result.Flasks[i].Bounds = bounds
+	}
+
+	// Runs go from the top of every flask, so walk them backwards.
+	sizes := make([]int, len(flasks))
+	for i := len(runs) - 1; i >= 0; i-- {
+		run := runs[i]
+		if run.pieces == 0 {
+			continue
+		}
+		clr := run.name
+		report := &result.Flasks[run.flask]
+		report.Runs = append(report.Runs, RunReport{Color: clr, Height: run.height, Pieces: run.pieces})
+
+		for p := 0; p < run.pieces; p++ {
+			if sizes[run.flask] == capacity {
+				result.addIssue(0.5, "flask %d has more than %d pieces, the top ones are dropped", run.flask+1, capacity)
+				break
+			}
+			result.State[run.flask][sizes[run.flask]] = clr
+			sizes[run.flask]++
+		}
+	}
+}
+
+func (r *Recognizer) paletteColors() []watersortpuzzle.Color {
+	var colors []watersortpuzzle.Color
+	for c := range watersortpuzzle.DefaultPalette {
+		colors = append(colors, c)
+	}
+	for c := range r.palette {
+		if _, ok := watersortpuzzle.DefaultPalette[c]; !ok {
+			colors = append(colors, c)
+		}
+	}
+	sort.Slice(colors, func(i, j int) bool { return colors[i] < colors[j] })
+	return colors
+}
+
+func (r *Recognizer) inPalette(c watersortpuzzle.Color) bool {
+	_, inDefault := watersortpuzzle.DefaultPalette[c]
+	_, inPalette := r.palette[c]
+	return inDefault || inPalette
+}
+
+func (r *Result) addIssue(confidence float64, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, fmt.Sprintf(format, args...))
+	if confidence < 0 {
+		confidence = 0
+	}
+	r.Confidence *= confidence
+}
@@ -0,0 +1,96 @@
+package recognize_test
+
+import (
+	"image"
+	_ "image/jpeg"
+	"os"
+	"testing"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/recognize"
+	"github.com/pkositsyn/water-sort-puzzle-solver/render"
+	"github.com/pkositsyn/water-sort-puzzle-solver/solvertest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecognizeRendered(t *testing.T) {
+	for _, tc := range []struct {
+		state string
+		scale int
+	}{
+		{state: "GOFP;GOOB;", scale: 1},
+		{state: "FORF;OORF;RFOR;;", scale: 1},
+		{state: "FORF;OORF;RFOR;;", scale: 3},
+		{state: "RPPR;BRFR;OGOO;VFGV;GBVO;BVPB;PFFG;;", scale: 2},
+		{state: "YOCG;BNAR;LYVC;GPOA;RLBN;VCYP;ONGL;BAPR;CVYN;AGLO;PRVB;;;", scale: 1},
+	} {
+		state := solvertest.MustState(t, tc.state)
+		img := render.NewRenderer(render.WithScale(tc.scale)).Image(state)
+
+		result, err := recognize.NewRecognizer().Recognize(img)
+		require.NoError(t, err)
+		require.Equal(t, tc.state, result.State.String())
+		require.Len(t, result.Flasks, len(state))
+		require.InDelta(t, float64(30*tc.scale), result.PieceHeight, 0.01)
+
+		if tc.state != "GOFP;GOOB;" {
+			require.Empty(t, result.Issues)
+			require.Equal(t, 1.0, result.Confidence)
+		}
+	}
+}
+
+func TestRecognizeRenderedStep(t *testing.T) {
+	state := solvertest.MustState(t, "FORF;OORF;RFOR;;")
+	img, err := render.NewRenderer().StepImage(state, watersortpuzzle.Step{From: 0, To: 4})
+	require.NoError(t, err)
+
+	result, err := recognize.NewRecognizer().Recognize(img)
+	require.NoError(t, err)
+	require.Equal(t, state.String(), result.State.String())
+}
+
+func TestRecognizeCustomPalette(t *testing.T) {
+	palette := watersortpuzzle.Palette{
+		'Q': {R: 0x10, G: 0xa0, B: 0x90, A: 0xff},
+		'T': {R: 0xa0, G: 0x20, B: 0x60, A: 0xff},
+	}
+	state := solvertest.MustState(t, "QTQT;TQTQ;;")
+	img := render.NewRenderer(render.WithPalette(palette)).Image(state)
+
+	result, err := recognize.NewRecognizer(recognize.WithPalette(palette)).Recognize(img)
+	require.NoError(t, err)
+	require.Equal(t, state.String(), result.State.String())
+	require.Equal(t, 1.0, result.Confidence)
+
+	// Without the palette colors are named by other letters, and it is reported.
+	result, err = recognize.NewRecognizer().Recognize(img)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(result.State[0].String()))
+	require.Less(t, result.Confidence, 1.0)
+	require.NotEmpty(t, result.Issues)
+}
+
+func TestRecognizeScreenshot(t *testing.T) {
+	file, err := os.Open("../pictures/3flasks.jpg")
+	require.NoError(t, err)
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	require.NoError(t, err)
+
+	// Green and pink of the game differ from the default palette.
+	palette, err := watersortpuzzle.ParsePalette("G=#789610,P=#e95e7b")
+	require.NoError(t, err)
+	result, err := recognize.NewRecognizer(recognize.WithPalette(palette)).Recognize(img)
+	require.NoError(t, err)
+	// The picture from README.
+	require.Equal(t, "GOFP;GOOB;", result.State.String())
+	// Only a part of the level is on the picture.
+	require.Len(t, result.Issues, 5)
+}
+
+func TestRecognizeNoFlasks(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	_, err := recognize.NewRecognizer().Recognize(img)
+	require.ErrorIs(t, err, recognize.ErrNoFlasks)
+}
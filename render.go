@@ -0,0 +1,71 @@
+package watersortpuzzle
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// RenderOptions configure State.Render.
+type RenderOptions struct {
+	// ANSI draws pieces with true color background. Use it only for terminals.
+	ANSI bool
+	// Palette is used with ANSI. Missing colors are taken from DefaultPalette.
+	Palette Palette
+}
+
+// Render draws the state as vertical flasks with 1-based flask numbers underneath.
+// Every line, including the last one, ends with "\n".
+func (s State) Render(opts RenderOptions) string {
+	var b strings.Builder
+	for row := waterPiecesPerFlask - 1; row >= 0; row-- {
+		for i, f := range s {
+			if i != 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteByte('|')
+			b.WriteString(renderPiece(f[row], opts))
+			b.WriteByte('|')
+		}
+		b.WriteByte('\n')
+	}
+
+	for i := range s {
+		if i != 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString("+---+")
+	}
+	b.WriteByte('\n')
+	for i := range s {
+		if i != 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%3d  ", i+1)
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+func renderPiece(c Color, opts RenderOptions) string {
+	if c == colorNone {
+		return "   "
+	}
+	if !opts.ANSI {
+		return fmt.Sprintf(" %c ", c)
+	}
+
+	rgba := opts.Palette.RGBA(c)
+	fg := ContrastColor(rgba)
+	return fmt.Sprintf("\x1b[48;2;%d;%d;%dm\x1b[38;2;%d;%d;%dm %c \x1b[0m",
+		rgba.R, rgba.G, rgba.B, fg.R, fg.G, fg.B, c)
+}
+
+// ContrastColor returns black or white, whichever is readable on background.
+func ContrastColor(background color.RGBA) color.RGBA {
+	// Perceived brightness by ITU-R BT.601.
+	if 299*int(background.R)+587*int(background.G)+114*int(background.B) > 128*1000 {
+		return color.RGBA{A: 0xff}
+	}
+	return color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+}
@@ -0,0 +1,162 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+)
+
+const capacity = watersortpuzzle.FlaskCapacity
+
+// layout computes positions of picture elements for a number of flasks.
+type layout struct {
+	scale  int
+	flasks int
+}
+
+func (r *Renderer) newLayout(flasks int) layout {
+	return layout{scale: r.scale, flasks: flasks}
+}
+
+func (l layout) tubeWidth() int {
+	return (pieceWidth + 2*wallWidth) * l.scale
+}
+
+func (l layout) tubeTop() int {
+	return (margin + arrowHeight) * l.scale
+}
+
+func (l layout) bounds() image.Rectangle {
+	width := 2 * margin * l.scale
+	if l.flasks > 0 {
+		width += l.flasks*l.tubeWidth() + (l.flasks-1)*tubesGap*l.scale
+	}
+	height := (margin + arrowHeight + capacity*pieceHeight + wallWidth + labelGap + labelHeight + margin) * l.scale
+	return image.Rect(0, 0, width, height)
+}
+
+// tube returns outer rectangle of flask i including walls.
+func (l layout) tube(i int) image.Rectangle {
+	left := margin*l.scale + i*(l.tubeWidth()+tubesGap*l.scale)
+	return image.Rect(left, l.tubeTop(), left+l.tubeWidth(), l.tubeTop()+(capacity*pieceHeight+wallWidth)*l.scale)
+}
+
+// walls returns left, right and bottom walls of flask i.
+func (l layout) walls(i int) []image.Rectangle {
+	tube := l.tube(i)
+	wall := wallWidth * l.scale
+	return []image.Rectangle{
+		image.Rect(tube.Min.X, tube.Min.Y, tube.Min.X+wall, tube.Max.Y),
+		image.Rect(tube.Max.X-wall, tube.Min.Y, tube.Max.X, tube.Max.Y),
+		image.Rect(tube.Min.X, tube.Max.Y-wall, tube.Max.X, tube.Max.Y),
+	}
+}
+
+// piece returns rectangle of piece at row of flask i. Row 0 is the bottom one.
+func (l layout) piece(i, row int) image.Rectangle {
+	tube := l.tube(i)
+	wall := wallWidth * l.scale
+	bottom := tube.Max.Y - wall - row*pieceHeight*l.scale
+	return image.Rect(tube.Min.X+wall, bottom-pieceHeight*l.scale, tube.Max.X-wall, bottom)
+}
+
+// label returns rectangle of 1-based number of flask i.
+func (l layout) label(i int) image.Rectangle {
+	tube := l.tube(i)
+	text := strconv.Itoa(i + 1)
+	dot := labelHeight / len(digitFont[0]) * l.scale
+	width := (len(text)*(digitWidth+1) - 1) * dot
+	left := (tube.Min.X+tube.Max.X)/2 - width/2
+	top := tube.Max.Y + labelGap*l.scale
+	return image.Rect(left, top, left+width, top+labelHeight*l.scale)
+}
+
+// arrow returns rectangles of arrow shaft from above flask from to above flask to,
+// and the tip of the arrow head pointing down at flask to.
+func (l layout) arrow(from, to int) (shaft []image.Rectangle, tip image.Point) {
+	half := arrowWidth * l.scale / 2
+	lineY := (margin + arrowHeight/4) * l.scale
+	endY := l.tubeTop() - wallWidth*l.scale
+	fromX := (l.tube(from).Min.X + l.tube(from).Max.X) / 2
+	toX := (l.tube(to).Min.X + l.tube(to).Max.X) / 2
+	left, right := fromX, toX
+	if left > right {
+		left, right = right, left
+	}
+
+	shaft = []image.Rectangle{
+		image.Rect(fromX-half, lineY-half, fromX+half, endY),
+		image.Rect(left-half, lineY-half, right+half, lineY+half),
+		image.Rect(toX-half, lineY-half, toX+half, endY-arrowHeadLen*l.scale),
+	}
+	return shaft, image.Pt(toX, endY)
+}
+
+func (r *Renderer) draw(img draw.Image, offset image.Point, state watersortpuzzle.State, step *watersortpuzzle.Step) {
+	l := r.newLayout(len(state))
+	fill(img, l.bounds().Add(offset), BackgroundColor)
+
+	for i, f := range state {
+		for _, wall := range l.walls(i) {
+			fill(img, wall.Add(offset), TubeColor)
+		}
+		for row, c := range f[:f.Size()] {
+			fill(img, l.piece(i, row).Add(offset), r.palette.RGBA(c))
+		}
+		drawNumber(img, l.label(i).Add(offset), i+1, LabelColor)
+	}
+
+	if step != nil {
+		shaft, tip := l.arrow(step.From, step.To)
+		for _, rect := range shaft {
+			fill(img, rect.Add(offset), ArrowColor)
+		}
+		drawArrowHead(img, tip.Add(offset), arrowHeadLen*l.scale, ArrowColor)
+	}
+}
+
+func fill(img draw.Image, rect image.Rectangle, c color.Color) {
+	draw.Draw(img, rect, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// drawArrowHead draws triangle of given height pointing down to tip.
+func drawArrowHead(img draw.Image, tip image.Point, height int, c color.Color) {
+	for dy := 0; dy < height; dy++ {
+		half := dy * 3 / 4
+		fill(img, image.Rect(tip.X-half, tip.Y-dy-1, tip.X+half+1, tip.Y-dy), c)
+	}
+}
+
+const digitWidth = 3
+
+// digitFont is a 3x5 bitmap font of digits.
+var digitFont = [10][5]string{
+	{"###", "#.#", "#.#", "#.#", "###"},
+	{".#.", "##.", ".#.", ".#.", "###"},
+	{"###", "..#", "###", "#..", "###"},
+	{"###", "..#", "###", "..#", "###"},
+	{"#.#", "#.#", "###", "..#", "..#"},
+	{"###", "#..", "###", "..#", "###"},
+	{"###", "#..", "###", "#.#", "###"},
+	{"###", "..#", "..#", "..#", "..#"},
+	{"###", "#.#", "###", "#.#", "###"},
+	{"###", "#.#", "###", "..#", "###"},
+}
+
+// drawNumber draws number into rect, which must be computed by layout.label.
+func drawNumber(img draw.Image, rect image.Rectangle, number int, c color.Color) {
+	dot := rect.Dy() / len(digitFont[0])
+	for i, digit := range strconv.Itoa(number) {
+		left := rect.Min.X + i*(digitWidth+1)*dot
+		for y, line := range digitFont[digit-'0'] {
+			for x, pixel := range line {
+				if pixel == '#' {
+					fill(img, image.Rect(left+x*dot, rect.Min.Y+y*dot, left+(x+1)*dot, rect.Min.Y+(y+1)*dot), c)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Package render draws states and solutions of the puzzle as SVG, PNG and GIF images.
+package render
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"time"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+)
+
+// ErrInvalidStep is returned, when step refers to flasks missing in state.
+var ErrInvalidStep = errors.New("step refers to missing flask")
+
+// Colors of picture elements other than pieces.
+var (
+	BackgroundColor = color.RGBA{R: 0x1e, G: 0x22, B: 0x30, A: 0xff}
+	TubeColor       = color.RGBA{R: 0xc8, G: 0xcc, B: 0xd8, A: 0xff}
+	LabelColor      = color.RGBA{R: 0x9a, G: 0xa0, B: 0xb4, A: 0xff}
+	ArrowColor      = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+)
+
+// Sizes of picture elements in pixels for scale 1.
+const (
+	margin       = 20
+	arrowHeight  = 50
+	pieceWidth   = 40
+	pieceHeight  = 30
+	wallWidth    = 4
+	tubesGap     = 16
+	labelGap     = 8
+	labelHeight  = 15 // digit of 3x5 font scaled by 3
+	arrowWidth   = 4
+	arrowHeadLen = 12
+)
+
+const (
+	defaultScale   = 1
+	defaultColumns = 4
+	defaultDelay   = time.Second
+)
+
+// Renderer draws pictures of the puzzle. Every flask is an open tube with its number underneath.
+type Renderer struct {
+	palette watersortpuzzle.Palette
+	scale   int
+	columns int
+	delay   time.Duration
+}
+
+type Option func(r *Renderer)
+
+// WithPalette sets colors of pieces. Missing colors are taken from DefaultPalette.
+func WithPalette(palette watersortpuzzle.Palette) Option {
+	return func(r *Renderer) {
+		r.palette = palette
+	}
+}
+
+// WithScale multiplies all sizes of picture. Default is 1, which gives 40px wide flasks.
+func WithScale(scale int) Option {
+	return func(r *Renderer) {
+		r.scale = scale
+	}
+}
+
+// WithColumns sets number of frames in one row of storyboard.
+func WithColumns(columns int) Option {
+	return func(r *Renderer) {
+		r.columns = columns
+	}
+}
+
+// WithDelay sets time between frames of GIF animation.
+func WithDelay(delay time.Duration) Option {
+	return func(r *Renderer) {
+		r.delay = delay
+	}
+}
+
+func NewRenderer(opts ...Option) *Renderer {
+	r := &Renderer{
+		scale:   defaultScale,
+		columns: defaultColumns,
+		delay:   defaultDelay,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.scale < 1 {
+		r.scale = 1
+	}
+	if r.columns < 1 {
+		r.columns = 1
+	}
+	return r
+}
+
+// Image draws state.
+func (r *Renderer) Image(state watersortpuzzle.State) *image.RGBA {
+	img := image.NewRGBA(r.newLayout(len(state)).bounds())
+	r.draw(img, image.Point{}, state, nil)
+	return img
+}
+
+// StepImage draws state with arrow from the source flask of step to the destination one.
+func (r *Renderer) StepImage(state watersortpuzzle.State, step watersortpuzzle.Step) (*image.RGBA, error) {
+	if err := checkStep(state, step); err != nil {
+		return nil, err
+	}
+	img := image.NewRGBA(r.newLayout(len(state)).bounds())
+	r.draw(img, image.Point{}, state, &step)
+	return img, nil
+}
+
+// PNG writes picture of state in PNG format.
+func (r *Renderer) PNG(w io.Writer, state watersortpuzzle.State) error {
+	return png.Encode(w, r.Image(state))
+}
+
+// Storyboard draws every step of solution on its own frame, and the final state last.
+// Frames go in rows of WithColumns frames.
+func (r *Renderer) Storyboard(state watersortpuzzle.State, steps []watersortpuzzle.Step) (*image.RGBA, error) {
+	frames, err := solutionFrames(state, steps)
+	if err != nil {
+		return nil, err
+	}
+
+	frameSize := r.newLayout(len(state)).bounds().Size()
+	columns := r.columns
+	if len(frames) < columns {
+		columns = len(frames)
+	}
+	rows := (len(frames) + columns - 1) / columns
+	img := image.NewRGBA(image.Rect(0, 0, frameSize.X*columns, frameSize.Y*rows))
+	fill(img, img.Bounds(), BackgroundColor)
+	for i, frame := range frames {
+		offset := image.Pt(i%columns*frameSize.X, i/columns*frameSize.Y)
+		r.draw(img, offset, frame.state, frame.step)
+	}
+	return img, nil
+}
+
+// GIF writes animation of solution: one frame for every step and the final state.
+func (r *Renderer) GIF(w io.Writer, state watersortpuzzle.State, steps []watersortpuzzle.Step) error {
+	frames, err := solutionFrames(state, steps)
+	if err != nil {
+		return err
+	}
+
+	colors := r.colors(state)
+	bounds := r.newLayout(len(state)).bounds()
+	rgba := image.NewRGBA(bounds)
+	animation := &gif.GIF{}
+	for _, frame := range frames {
+		r.draw(rgba, image.Point{}, frame.state, frame.step)
+		paletted := image.NewPaletted(bounds, colors)
+		draw.Draw(paletted, bounds, rgba, image.Point{}, draw.Src)
+		animation.Image = append(animation.Image, paletted)
+		animation.Delay = append(animation.Delay, int(r.delay/(10*time.Millisecond)))
+	}
+	return gif.EncodeAll(w, animation)
+}
+
+// colors returns GIF palette with exact colors of picture elements and pieces of state.
+// GIF allows only 256 colors, pieces of other colors are drawn with the closest ones.
+func (r *Renderer) colors(state watersortpuzzle.State) color.Palette {
+	colors := color.Palette{BackgroundColor, TubeColor, LabelColor, ArrowColor}
+	seen := make(map[watersortpuzzle.Color]struct{})
+	for _, f := range state {
+		for _, c := range f[:f.Size()] {
+			if _, ok := seen[c]; ok {
+				continue
+			}
+			seen[c] = struct{}{}
+			if len(colors) < 256 {
+				colors = append(colors, r.palette.RGBA(c))
+			}
+		}
+	}
+	return colors
+}
+
+type frame struct {
+	state watersortpuzzle.State
+	step  *watersortpuzzle.Step
+}
+
+func solutionFrames(state watersortpuzzle.State, steps []watersortpuzzle.Step) ([]frame, error) {
+	frames := make([]frame, 0, len(steps)+1)
+	for i := range steps {
+		step := steps[i]
+		newState, err := state.Step(step)
+		if err != nil {
+			return nil, fmt.Errorf("invalid step %d: %w", i+1, err)
+		}
+		frames = append(frames, frame{state: state, step: &step})
+		state = newState
+	}
+	return append(frames, frame{state: state}), nil
+}
+
+func checkStep(state watersortpuzzle.State, step watersortpuzzle.Step) error {
+	if step.From < 0 || step.From >= len(state) || step.To < 0 || step.To >= len(state) {
+		return fmt.Errorf("%w: %d -> %d of %d flasks", ErrInvalidStep, step.From+1, step.To+1, len(state))
+	}
+	return nil
+}
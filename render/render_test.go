@@ -0,0 +1,101 @@
+package render_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"strings"
+	"testing"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/render"
+	"github.com/pkositsyn/water-sort-puzzle-solver/solvertest"
+	"github.com/stretchr/testify/require"
+)
+
+func countPixels(img image.Image, c color.Color) int {
+	r, g, b, a := c.RGBA()
+	var count int
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r1, g1, b1, a1 := img.At(x, y).RGBA()
+			if r == r1 && g == g1 && b == b1 && a == a1 {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func TestRendererPNG(t *testing.T) {
+	state := solvertest.MustState(t, "GOOR;GOR;")
+	palette := watersortpuzzle.Palette{'R': {R: 0xff, A: 0xff}}
+	renderer := render.NewRenderer(render.WithPalette(palette))
+
+	var buf bytes.Buffer
+	require.NoError(t, renderer.PNG(&buf, state))
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+
+	// Areas of pieces are proportional to number of pieces.
+	green := countPixels(img, watersortpuzzle.DefaultPalette['G'])
+	require.NotZero(t, green)
+	require.Equal(t, 3*green/2, countPixels(img, watersortpuzzle.DefaultPalette['O']))
+	require.Equal(t, green, countPixels(img, palette['R']))
+	require.Zero(t, countPixels(img, watersortpuzzle.DefaultPalette['R']))
+
+	bigger := render.NewRenderer(render.WithScale(2)).Image(state)
+	require.Equal(t, img.Bounds().Size().Mul(2), bigger.Bounds().Size())
+	require.Equal(t, 4*green, countPixels(bigger, watersortpuzzle.DefaultPalette['G']))
+}
+
+func TestRendererStepImage(t *testing.T) {
+	state := solvertest.MustState(t, "GOOR;GOR;")
+	renderer := render.NewRenderer()
+
+	img, err := renderer.StepImage(state, watersortpuzzle.Step{From: 0, To: 2})
+	require.NoError(t, err)
+	require.NotZero(t, countPixels(img, render.ArrowColor))
+	require.Zero(t, countPixels(renderer.Image(state), render.ArrowColor))
+
+	_, err = renderer.StepImage(state, watersortpuzzle.Step{From: 0, To: 3})
+	require.ErrorIs(t, err, render.ErrInvalidStep)
+}
+
+func TestRendererSolution(t *testing.T) {
+	state := solvertest.MustState(t, "FORF;OORF;RFOR;;")
+	steps, err := watersortpuzzle.NewAStarSolver().Solve(state)
+	require.NoError(t, err)
+
+	renderer := render.NewRenderer(render.WithColumns(3))
+	frameSize := renderer.Image(state).Bounds().Size()
+	storyboard, err := renderer.Storyboard(state, steps)
+	require.NoError(t, err)
+	rows := (len(steps) + 1 + 2) / 3
+	require.Equal(t, image.Pt(3*frameSize.X, rows*frameSize.Y), storyboard.Bounds().Size())
+
+	var buf bytes.Buffer
+	require.NoError(t, renderer.GIF(&buf, state, steps))
+	animation, err := gif.DecodeAll(&buf)
+	require.NoError(t, err)
+	require.Len(t, animation.Image, len(steps)+1)
+	require.Equal(t, countPixels(renderer.Image(state), watersortpuzzle.DefaultPalette['F']),
+		countPixels(animation.Image[0], watersortpuzzle.DefaultPalette['F']))
+
+	_, err = renderer.Storyboard(state, []watersortpuzzle.Step{{From: 3, To: 0}})
+	require.ErrorIs(t, err, watersortpuzzle.ErrEmptyFlask)
+}
+
+func TestRendererSVG(t *testing.T) {
+	state := solvertest.MustState(t, "GOOR;GOR;")
+
+	var buf bytes.Buffer
+	require.NoError(t, render.NewRenderer().SVG(&buf, state))
+	svg := buf.String()
+	require.True(t, strings.HasPrefix(svg, "<svg "))
+	require.Equal(t, 3, strings.Count(svg, `fill="#f28c28"`))
+	require.Contains(t, svg, ">3</text>")
+}
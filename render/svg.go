@@ -0,0 +1,47 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+)
+
+// SVG writes picture of state in SVG format. It has the same layout as Image.
+func (r *Renderer) SVG(w io.Writer, state watersortpuzzle.State) error {
+	l := r.newLayout(len(state))
+	bounds := l.bounds()
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		bounds.Dx(), bounds.Dy(), bounds.Dx(), bounds.Dy())
+	writeSVGRect(out, bounds, BackgroundColor)
+
+	for i, f := range state {
+		for _, wall := range l.walls(i) {
+			writeSVGRect(out, wall, TubeColor)
+		}
+		for row, c := range f[:f.Size()] {
+			writeSVGRect(out, l.piece(i, row), r.palette.RGBA(c))
+		}
+
+		label := l.label(i)
+		fmt.Fprintf(out, `<text x="%d" y="%d" fill="%s" font-family="monospace" font-size="%d" text-anchor="middle">%d</text>`+"\n",
+			(label.Min.X+label.Max.X)/2, label.Max.Y, hexColor(LabelColor), label.Dy()*4/3, i+1)
+	}
+
+	fmt.Fprintln(out, "</svg>")
+	return out.Flush()
+}
+
+func writeSVGRect(w io.Writer, rect image.Rectangle, c color.RGBA) {
+	fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+		rect.Min.X, rect.Min.Y, rect.Dx(), rect.Dy(), hexColor(c))
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,169 @@
+// Package simulate plays levels by simple strategies of novice players.
+//
+// Results of many runs tell, how likely a real player is to get stuck on a level.
+// Simulated players don't return to a position seen in the same run, when they
+// have another choice. A run ends, when the level is solved, there are no legal
+// steps or the player makes too many steps.
+package simulate
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+)
+
+const (
+	defaultRuns     = 100
+	defaultMaxMoves = 200
+)
+
+// Run is one game of simulated player.
+type Run struct {
+	Steps  []watersortpuzzle.Step
+	Solved bool
+}
+
+// Report sums up runs of one strategy.
+type Report struct {
+	Strategy string `json:"strategy"`
+	Runs     int    `json:"runs"`
+	Solved   int    `json:"solved"`
+	// SuccessRate is the share of solved runs from 0 to 1.
+	SuccessRate float64 `json:"success_rate"`
+	// AverageMoves is the average number of steps of solved runs.
+	AverageMoves float64 `json:"average_moves"`
+	// Stuck is the number of runs ended without legal steps.
+	Stuck int `json:"stuck"`
+	// TooLong is the number of runs stopped after the maximum number of steps.
+	TooLong int `json:"too_long"`
+}
+
+// Simulator plays levels many times. It isn't safe for concurrent use.
+type Simulator struct {
+	runs     int
+	maxMoves int
+	seed     int64
+}
+
+type Option func(s *Simulator)
+
+// WithRuns sets the number of runs of every strategy. Default is 100.
+func WithRuns(runs int) Option {
+	return func(s *Simulator) {
+		s.runs = runs
+	}
+}
+
+// WithMaxMoves sets the number of steps, after which run is considered failed. Default is 200.
+func WithMaxMoves(maxMoves int) Option {
+	return func(s *Simulator) {
+		s.maxMoves = maxMoves
+	}
+}
+
+// WithSeed sets seed of random choices. Default is 1.
+func WithSeed(seed int64) Option {
+	return func(s *Simulator) {
+		s.seed = seed
+	}
+}
+
+func NewSimulator(opts ...Option) *Simulator {
+	s := &Simulator{
+		runs:     defaultRuns,
+		maxMoves: defaultMaxMoves,
+		seed:     1,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Simulate plays state by strategy the number of runs. The same seed gives the same report.
+func (s *Simulator) Simulate(state watersortpuzzle.State, strategy Strategy) Report {
+	rnd := rand.New(rand.NewSource(s.seed))
+	report := Report{Strategy: strategy.Name(), Runs: s.runs}
+	var solvedMoves int
+	for i := 0; i < s.runs; i++ {
+		run := Play(state, strategy, rnd, s.maxMoves)
+		switch {
+		case run.Solved:
+			report.Solved++
+			solvedMoves += len(run.Steps)
+		case len(run.Steps) >= s.maxMoves:
+			report.TooLong++
+		default:
+			report.Stuck++
+		}
+	}
+
+	if report.Runs != 0 {
+		report.SuccessRate = float64(report.Solved) / float64(report.Runs)
+	}
+	if report.Solved != 0 {
+		report.AverageMoves = float64(solvedMoves) / float64(report.Solved)
+	}
+	return report
+}
+
+// Play plays state by strategy once, making at most maxMoves steps.
+func Play(state watersortpuzzle.State, strategy Strategy, rnd *rand.Rand, maxMoves int) Run {
+	var run Run
+	seen := map[string]struct{}{state.EquivalentString(): {}}
+	for !state.IsTerminal() {
+		if len(run.Steps) >= maxMoves {
+			return run
+		}
+		steps := state.LegalSteps()
+		if len(steps) == 0 {
+			return run
+		}
+		if fresh := freshSteps(state, steps, seen); len(fresh) != 0 {
+			steps = fresh
+		}
+
+		step := strategy.Choose(state, steps, rnd)
+		newState, err := state.Step(step)
+		if err != nil {
+			panic(fmt.Sprintf("strategy %s chose illegal step: %s", strategy.Name(), err.Error()))
+		}
+		state = newState
+		seen[state.EquivalentString()] = struct{}{}
+		run.Steps = append(run.Steps, step)
+	}
+	run.Solved = true
+	return run
+}
+
+// freshSteps returns steps to positions, which are not seen yet.
+func freshSteps(state watersortpuzzle.State, steps []watersortpuzzle.Step, seen map[string]struct{}) []watersortpuzzle.Step {
+	var fresh []watersortpuzzle.Step
+	for _, step := range steps {
+		newState, err := state.Step(step)
+		if err != nil {
+			continue
+		}
+		if _, ok := seen[newState.EquivalentString()]; !ok {
+			fresh = append(fresh, step)
+		}
+	}
+	return fresh
+}
+
+// String is human-readable description of report.
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-15s solved %3.0f%% of %d runs", r.Strategy, r.SuccessRate*100, r.Runs)
+	if r.Solved != 0 {
+		fmt.Fprintf(&b, " in %.1f moves on average", r.AverageMoves)
+	}
+	if r.Stuck != 0 || r.TooLong != 0 {
+		fmt.Fprintf(&b, ", %d got stuck, %d made too many moves", r.Stuck, r.TooLong)
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
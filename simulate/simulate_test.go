@@ -0,0 +1,75 @@
+package simulate_test
+
+import (
+	"math/rand"
+	"testing"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/simulate"
+	"github.com/pkositsyn/water-sort-puzzle-solver/solvertest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlay(t *testing.T) {
+	state := solvertest.MustState(t, "FORF;OORF;RFOR;;")
+	for _, strategy := range simulate.Strategies() {
+		run := simulate.Play(state, strategy, rand.New(rand.NewSource(1)), 200)
+
+		current := state
+		for _, step := range run.Steps {
+			var err error
+			current, err = current.Step(step)
+			require.NoError(t, err, strategy.Name())
+		}
+		require.Equal(t, run.Solved, current.IsTerminal(), strategy.Name())
+	}
+}
+
+func TestPlayStuck(t *testing.T) {
+	run := simulate.Play(solvertest.MustState(t, "AABB;BBAA"), simulate.Random, rand.New(rand.NewSource(1)), 200)
+	require.False(t, run.Solved)
+	require.Empty(t, run.Steps)
+
+	run = simulate.Play(solvertest.MustState(t, "FORF;OORF;RFOR;;"), simulate.Random, rand.New(rand.NewSource(1)), 3)
+	require.False(t, run.Solved)
+	require.Len(t, run.Steps, 3)
+}
+
+func TestCompleteFirst(t *testing.T) {
+	// Pouring O into the second flask completes it, other steps don't.
+	state := solvertest.MustState(t, "RO;OOO;RR;")
+	for seed := int64(0); seed < 10; seed++ {
+		step := simulate.CompleteFirst.Choose(state, state.LegalSteps(), rand.New(rand.NewSource(seed)))
+		require.Equal(t, watersortpuzzle.Step{From: 0, To: 1}, step)
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	state := solvertest.MustState(t, "FORF;OORF;RFOR;;")
+	simulator := simulate.NewSimulator(simulate.WithRuns(50), simulate.WithSeed(3))
+	for _, strategy := range simulate.Strategies() {
+		report := simulator.Simulate(state, strategy)
+		require.Equal(t, strategy.Name(), report.Strategy)
+		require.Equal(t, 50, report.Runs)
+		require.Equal(t, report.Runs, report.Solved+report.Stuck+report.TooLong)
+		require.InDelta(t, float64(report.Solved)/50, report.SuccessRate, 1e-9)
+		if report.Solved != 0 {
+			require.GreaterOrEqual(t, report.AverageMoves, 10.0)
+		}
+		require.Equal(t, report, simulator.Simulate(state, strategy))
+	}
+
+	greedy := simulator.Simulate(state, simulate.Greedy)
+	random := simulator.Simulate(state, simulate.Random)
+	require.Greater(t, greedy.SuccessRate, 0.0)
+	require.GreaterOrEqual(t, greedy.SuccessRate, random.SuccessRate)
+}
+
+func TestStrategyByName(t *testing.T) {
+	strategy, ok := simulate.StrategyByName("complete-first")
+	require.True(t, ok)
+	require.Equal(t, simulate.CompleteFirst, strategy)
+
+	_, ok = simulate.StrategyByName("expert")
+	require.False(t, ok)
+}
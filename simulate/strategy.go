@@ -0,0 +1,98 @@
+package simulate
+
+import (
+	"math/rand"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+)
+
+// Strategy chooses the next step of simulated player.
+type Strategy interface {
+	Name() string
+	// Choose returns one of steps, which are legal in state. Steps are never empty.
+	Choose(state watersortpuzzle.State, steps []watersortpuzzle.Step, rnd *rand.Rand) watersortpuzzle.Step
+}
+
+// Strategies of simulated players.
+var (
+	// Random makes any legal step.
+	Random Strategy = randomStrategy{}
+	// Greedy makes a step to position of the lowest State.Heuristic, ties are broken randomly.
+	Greedy Strategy = greedyStrategy{}
+	// CompleteFirst finishes a flask whenever possible, otherwise makes any legal step.
+	CompleteFirst Strategy = completeFirstStrategy{}
+)
+
+// Strategies returns all strategies of the package.
+func Strategies() []Strategy {
+	return []Strategy{Random, Greedy, CompleteFirst}
+}
+
+// StrategyByName returns strategy with the name or false.
+func StrategyByName(name string) (Strategy, bool) {
+	for _, strategy := range Strategies() {
+		if strategy.Name() == name {
+			return strategy, true
+		}
+	}
+	return nil, false
+}
+
+type randomStrategy struct{}
+
+func (randomStrategy) Name() string {
+	return "random"
+}
+
+func (randomStrategy) Choose(_ watersortpuzzle.State, steps []watersortpuzzle.Step, rnd *rand.Rand) watersortpuzzle.Step {
+	return steps[rnd.Intn(len(steps))]
+}
+
+type greedyStrategy struct{}
+
+func (greedyStrategy) Name() string {
+	return "greedy"
+}
+
+func (greedyStrategy) Choose(state watersortpuzzle.State, steps []watersortpuzzle.Step, rnd *rand.Rand) watersortpuzzle.Step {
+	var best []watersortpuzzle.Step
+	var bestHeuristic int
+	for _, step := range steps {
+		newState, err := state.Step(step)
+		if err != nil {
+			panic("logic error: illegal step given to strategy")
+		}
+		heuristic := newState.Heuristic()
+		if len(best) == 0 || heuristic < bestHeuristic {
+			best, bestHeuristic = best[:0], heuristic
+		}
+		if heuristic == bestHeuristic {
+			best = append(best, step)
+		}
+	}
+	return best[rnd.Intn(len(best))]
+}
+
+type completeFirstStrategy struct{}
+
+func (completeFirstStrategy) Name() string {
+	return "complete-first"
+}
+
+func (completeFirstStrategy) Choose(state watersortpuzzle.State, steps []watersortpuzzle.Step, rnd *rand.Rand) watersortpuzzle.Step {
+	var completing []watersortpuzzle.Step
+	for _, step := range steps {
+		newState, err := state.Step(step)
+		if err != nil {
+			panic("logic error: illegal step given to strategy")
+		}
+		// Pouring a finished flask into an empty one doesn't complete anything.
+		if to, from := newState[step.To], state[step.From]; to.IsFull() && to.IsFinished() && !from.IsFinished() {
+			completing = append(completing, step)
+		}
+	}
+	if len(completing) != 0 {
+		return completing[rnd.Intn(len(completing))]
+	}
+	return steps[rnd.Intn(len(steps))]
+}
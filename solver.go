@@ -2,14 +2,24 @@ package watersortpuzzle
 
 import (
 	"container/heap"
+	"context"
 	"errors"
+	"fmt"
 	"math"
+	"sort"
 )
 
 type Step struct {
 	From, To int
 }
 
+// Solver finds minimal sequence of steps leading from initial state to a terminal one.
+//
+// Solvers are deterministic: the same initial state always gives the same solution.
+// When there are several optimal solutions, ties are broken in the following way:
+//   - successors of a state are generated in ascending (From, To) order of steps;
+//   - A* takes states with equal f from the heap according to its TieBreaking;
+//   - IDA* expands successors according to its MoveOrdering.
 type Solver interface {
 	Solve(initialState State) ([]Step, error)
 }
@@ -19,28 +29,51 @@ type SolverWithStats interface {
 	Stats() Stats
 }
 
+// ResumableSolver can be interrupted via context and continued later from a checkpoint.
+type ResumableSolver interface {
+	SolverWithStats
+	SolveContext(ctx context.Context, initialState State) ([]Step, error)
+	// Checkpoint returns snapshot of the search interrupted by context.
+	Checkpoint() (*Checkpoint, error)
+	// Resume continues the search from checkpoint. Solver options must be the same as of interrupted one.
+	Resume(ctx context.Context, checkpoint *Checkpoint) ([]Step, error)
+}
+
 var ErrNotExist = errors.New("solution doesn't exist")
 
+// contextCheckInterval is number of expanded states between checks of context cancellation.
+const contextCheckInterval = 1024
+
 type AStarSolver struct {
-	heap      *distanceHeap
-	parents   map[string]aStarParent
-	heapElems map[string]*distanceHeapElem
-	heuristic func(State) int
-	stats     Stats
+	heap         *distanceHeap
+	parents      map[string]aStarParent
+	heapElems    map[string]*distanceHeapElem
+	heuristic    func(State) int
+	stats        Stats
+	initialState State
+	interrupted  bool
+	// algorithm labels checkpoints, so that they are resumed with the same heuristic.
+	algorithm string
+
+	tieBreaking TieBreaking
 }
 
 type Stats struct {
-	Steps int
+	Steps int `json:"steps"`
+
+	// TranspositionHits is number of states found in transposition table.
+	TranspositionHits int `json:"transposition_hits"`
+	// TranspositionCutoffs is number of states not expanded thanks to transposition table.
+	TranspositionCutoffs int `json:"transposition_cutoffs"`
 }
 
-var _ Solver = (*AStarSolver)(nil)
+var _ ResumableSolver = (*AStarSolver)(nil)
 
 func NewAStarSolver(opts ...AStarOption) *AStarSolver {
 	solver := &AStarSolver{
-		heap:      newDistanceHeap(),
-		parents:   make(map[string]aStarParent),
-		heapElems: make(map[string]*distanceHeapElem),
-		heuristic: func(s State) int { return s.Heuristic() },
+		heuristic:   func(s State) int { return s.Heuristic() },
+		algorithm:   checkpointAlgorithmAStar,
+		tieBreaking: TieBreakLargerG,
 	}
 
 	for _, opt := range opts {
@@ -62,13 +95,60 @@ func AStarWithHeuristic(heuristic func(State) int) AStarOption {
 	}
 }
 
+// TieBreaking is a policy of choosing between states with equal f in A* heap.
+type TieBreaking int
+
+const (
+	// TieBreakLargerG prefers states farther from the initial one, the rest of ties are FIFO.
+	// Deeper states are usually closer to solution, so this cuts expansions a lot.
+	TieBreakLargerG TieBreaking = iota
+
+	// TieBreakFIFO prefers states pushed to heap earlier.
+	TieBreakFIFO
+
+	// TieBreakLIFO prefers states pushed to heap later.
+	TieBreakLIFO
+)
+
+// TieBreakSmallerH prefers states with smaller heuristic.
+// As f = g + h, it is the same policy as TieBreakLargerG.
+const TieBreakSmallerH = TieBreakLargerG
+
+func (t TieBreaking) String() string {
+	switch t {
+	case TieBreakLargerG:
+		return "larger-g"
+	case TieBreakFIFO:
+		return "fifo"
+	case TieBreakLIFO:
+		return "lifo"
+	}
+	return fmt.Sprintf("TieBreaking(%d)", int(t))
+}
+
+func AStarWithTieBreaking(tieBreaking TieBreaking) AStarOption {
+	return func(solver *AStarSolver) {
+		solver.tieBreaking = tieBreaking
+	}
+}
+
 func NewDijkstraSolver() *AStarSolver {
-	return NewAStarSolver(AStarWithHeuristic(func(state State) int {
+	solver := NewAStarSolver(AStarWithHeuristic(func(state State) int {
 		return 0
 	}))
+	solver.algorithm = checkpointAlgorithmDijkstra
+	return solver
 }
 
 func (s *AStarSolver) Solve(initialState State) ([]Step, error) {
+	return s.SolveContext(context.Background(), initialState)
+}
+
+// SolveContext is like Solve, but stops when ctx is done.
+// Then the search can be continued from Checkpoint.
+func (s *AStarSolver) SolveContext(ctx context.Context, initialState State) ([]Step, error) {
+	s.reset(initialState)
+
 	newHeapElem := &distanceHeapElem{
 		distance: s.heuristic(initialState),
 		elem:     initialState,
@@ -78,7 +158,29 @@ func (s *AStarSolver) Solve(initialState State) ([]Step, error) {
 	heap.Push(s.heap, newHeapElem)
 	s.parents[stateStr] = aStarParent{parents: nil, distance: 0}
 
+	return s.search(ctx)
+}
+
+func (s *AStarSolver) reset(initialState State) {
+	s.heap = newDistanceHeap(s.tieBreaking)
+	s.parents = make(map[string]aStarParent)
+	s.heapElems = make(map[string]*distanceHeapElem)
+	s.stats = Stats{}
+	s.initialState = initialState
+	s.interrupted = false
+}
+
+func (s *AStarSolver) search(ctx context.Context) ([]Step, error) {
+	var stateStr string
+	var newHeapElem *distanceHeapElem
 	for s.heap.Len() > 0 {
+		if s.stats.Steps%contextCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				s.interrupted = true
+				return nil, fmt.Errorf("search interrupted: %w", err)
+			}
+		}
+
 		s.stats.Steps++
 		vertex := heap.Pop(s.heap).(*distanceHeapElem)
 		state := vertex.elem
@@ -165,17 +267,29 @@ func (s *AStarSolver) Stats() Stats {
 
 type IDAStarSolver struct {
 	heuristic    func(State) int
+	moveOrdering MoveOrdering
+	tableSize    int
+	tablePolicy  ReplacementPolicy
+	table        *transpositionTable
 	path         []State
 	pathVertices map[string]struct{}
 	stats        Stats
+
+	// interruption is filled while unwinding the search interrupted by context.
+	interruption *IDAStarCheckpoint
+	// resumeFrames are consumed while descending along the path of resumed search.
+	resumeFrames []IDAStarCheckpointFrame
+	resumeDepth  int
 }
 
-var _ Solver = (*IDAStarSolver)(nil)
+var _ ResumableSolver = (*IDAStarSolver)(nil)
 
 func NewIDAStarSolver(opts ...IDAStarOption) *IDAStarSolver {
 	solver := &IDAStarSolver{
 		heuristic:    func(s State) int { return s.Heuristic() },
-		pathVertices: make(map[string]struct{}),
+		moveOrdering: MoveOrderingHeuristic,
+		tableSize:    defaultTranspositionTableSize,
+		tablePolicy:  ReplaceShallower,
 	}
 
 	for _, opt := range opts {
@@ -192,56 +306,204 @@ func IDAStarWithHeuristic(heuristic func(State) int) IDAStarOption {
 	}
 }
 
+// MoveOrdering is a policy of ordering successors during IDA* search.
+type MoveOrdering int
+
+const (
+	// MoveOrderingHeuristic expands successors with the lowest f first.
+	// Among equal ones moves completing more flasks go first,
+	// the rest of ties keep order of State.ReachableStates.
+	MoveOrderingHeuristic MoveOrdering = iota
+
+	// MoveOrderingNone expands successors in order of State.ReachableStates.
+	MoveOrderingNone
+)
+
+func IDAStarWithMoveOrdering(ordering MoveOrdering) IDAStarOption {
+	return func(solver *IDAStarSolver) {
+		solver.moveOrdering = ordering
+	}
+}
+
+// IDAStarWithTranspositionTable sets number of entries in transposition table
+// and the policy of resolving collisions in it. Non-positive size disables the table.
+func IDAStarWithTranspositionTable(size int, policy ReplacementPolicy) IDAStarOption {
+	return func(solver *IDAStarSolver) {
+		solver.tableSize, solver.tablePolicy = size, policy
+	}
+}
+
 func (s *IDAStarSolver) Solve(initialState State) ([]Step, error) {
+	return s.SolveContext(context.Background(), initialState)
+}
+
+// SolveContext is like Solve, but stops when ctx is done.
+// Then the search can be continued from Checkpoint.
+func (s *IDAStarSolver) SolveContext(ctx context.Context, initialState State) ([]Step, error) {
+	s.reset(initialState)
+	return s.search(ctx, s.heuristic(initialState))
+}
+
+func (s *IDAStarSolver) reset(initialState State) {
+	s.table = newTranspositionTable(s.tableSize, s.tablePolicy)
 	s.path = []State{initialState}
-	s.pathVertices[initialState.String()] = struct{}{}
+	s.pathVertices = map[string]struct{}{initialState.String(): {}}
+	s.stats = Stats{}
+	s.interruption = nil
+	s.resumeFrames = nil
+	s.resumeDepth = 0
+}
 
-	minDistance := s.heuristic(initialState)
-	var found bool
+func (s *IDAStarSolver) search(ctx context.Context, minDistance int) ([]Step, error) {
+	initialState := s.path[0]
+	heuristic := s.heuristic(initialState)
 	for {
-		minDistance, found = s.iterate(initialState, minDistance)
+		newMinDistance, found, _ := s.iterate(ctx, initialState, heuristic, minDistance)
+		if s.interruption != nil {
+			s.interruption.Bound = minDistance
+			frames := s.interruption.Frames
+			for i := 0; i < len(frames)/2; i++ {
+				frames[i], frames[len(frames)-1-i] = frames[len(frames)-1-i], frames[i]
+			}
+			return nil, fmt.Errorf("search interrupted: %w", ctx.Err())
+		}
+		s.resumeFrames = nil
+
 		if found {
 			return s.composePath(), nil
 		}
-		if minDistance == math.MaxInt {
+		if newMinDistance == math.MaxInt {
 			return nil, ErrNotExist
 		}
+		minDistance = newMinDistance
 	}
 }
 
-func (s *IDAStarSolver) iterate(state State, minDistance int) (newMinDistance int, found bool) {
-	s.stats.Steps++
-	newDistance := len(s.path) + s.heuristic(state)
-	if newDistance > minDistance {
-		return newDistance, false
+// iterate searches for terminal state within minDistance.
+// It also reports whether the result depends on the current path,
+// i.e. some successors were skipped as already being on it. Such results are not
+// stored in transposition table, because they can be wrong for other paths.
+func (s *IDAStarSolver) iterate(ctx context.Context, state State, heuristic int, minDistance int) (newMinDistance int, found, pathDependent bool) {
+	distance := len(s.path)
+	var key string
+	if s.table != nil {
+		key = state.EquivalentString()
 	}
 
-	if state.IsTerminal() {
-		return 0, true
+	newMinDistance = math.MaxInt
+	var next int
+	if frame, ok := s.nextResumeFrame(distance - 1); ok {
+		// State was already checked and partially expanded before interruption.
+		newMinDistance, pathDependent, next = frame.MinDistance, frame.PathDependent, frame.Next
+	} else {
+		if s.stats.Steps%contextCheckInterval == 0 && ctx.Err() != nil {
+			s.interruption = &IDAStarCheckpoint{}
+			for _, pathState := range s.path {
+				s.interruption.Path = append(s.interruption.Path, pathState.String())
+			}
+			return 0, false, false
+		}
+
+		s.stats.Steps++
+		newDistance := distance + heuristic
+		if newDistance > minDistance {
+			return newDistance, false, false
+		}
+
+		if state.IsTerminal() {
+			return 0, true, false
+		}
+
+		if s.table != nil {
+			if bound, ok := s.table.lookup(key, distance); ok {
+				s.stats.TranspositionHits++
+				if bound > minDistance {
+					s.stats.TranspositionCutoffs++
+					return bound, false, false
+				}
+			}
+		}
 	}
 
-	newMinDistance = math.MaxInt
-	for _, newState := range state.ReachableStates() {
-		newStateStr := newState.String()
-		if _, ok := s.pathVertices[newStateStr]; ok {
+	successors := s.successors(state)
+	for i := next; i < len(successors); i++ {
+		successor := successors[i]
+		if _, ok := s.pathVertices[successor.str]; ok {
+			pathDependent = true
 			continue
 		}
-		s.pathVertices[newStateStr] = struct{}{}
-		s.path = append(s.path, newState)
-
-		newReachableDistance, found := s.iterate(newState, minDistance)
+		s.pathVertices[successor.str] = struct{}{}
+		s.path = append(s.path, successor.state)
+
+		newReachableDistance, found, successorPathDependent := s.iterate(ctx, successor.state, successor.heuristic, minDistance)
+		if s.interruption != nil {
+			s.interruption.Frames = append(s.interruption.Frames, IDAStarCheckpointFrame{
+				Next:          i,
+				MinDistance:   newMinDistance,
+				PathDependent: pathDependent,
+			})
+			return 0, false, false
+		}
 		if found {
-			return 0, true
+			return 0, true, false
 		}
 
 		if newReachableDistance < newMinDistance {
 			newMinDistance = newReachableDistance
 		}
+		pathDependent = pathDependent || successorPathDependent
 
-		delete(s.pathVertices, newStateStr)
+		delete(s.pathVertices, successor.str)
 		s.path = s.path[:len(s.path)-1]
 	}
-	return newMinDistance, false
+
+	if s.table != nil && !pathDependent {
+		s.table.store(key, distance, newMinDistance)
+	}
+	return newMinDistance, false, pathDependent
+}
+
+// nextResumeFrame returns saved frame for the state at depth on the path of resumed search.
+func (s *IDAStarSolver) nextResumeFrame(depth int) (IDAStarCheckpointFrame, bool) {
+	if depth != s.resumeDepth || depth >= len(s.resumeFrames) {
+		return IDAStarCheckpointFrame{}, false
+	}
+	s.resumeDepth++
+	return s.resumeFrames[depth], true
+}
+
+type idaStarSuccessor struct {
+	state          State
+	str            string
+	heuristic      int
+	finishedFlasks int
+}
+
+// successors returns states reachable from state in order of expansion.
+func (s *IDAStarSolver) successors(state State) []idaStarSuccessor {
+	reachable := state.ReachableStates()
+	successors := make([]idaStarSuccessor, 0, len(reachable))
+	for _, newState := range reachable {
+		successors = append(successors, idaStarSuccessor{
+			state:          newState,
+			str:            newState.String(),
+			heuristic:      s.heuristic(newState),
+			finishedFlasks: newState.finishedFlasks(),
+		})
+	}
+
+	if s.moveOrdering == MoveOrderingNone {
+		return successors
+	}
+
+	// All successors share the same g, so ordering by h is ordering by f.
+	sort.SliceStable(successors, func(i, j int) bool {
+		if successors[i].heuristic != successors[j].heuristic {
+			return successors[i].heuristic < successors[j].heuristic
+		}
+		return successors[i].finishedFlasks > successors[j].finishedFlasks
+	})
+	return successors
 }
 
 func (s *IDAStarSolver) Stats() Stats {
@@ -5,6 +5,7 @@ import (
 
 	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
 	"github.com/pkositsyn/water-sort-puzzle-solver/solvertest"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -68,3 +69,218 @@ func TestIDAStarSolver(t *testing.T) {
 func BenchmarkIDAStarSolver(b *testing.B) {
 	solvertest.TemplateBenchmarkSolve(b, idaStarFactoryMethod)
 }
+
+type IDAStarNoMoveOrderingSolverSuite struct {
+	solvertest.SolverSuite
+}
+
+func idaStarNoMoveOrderingFactoryMethod() watersortpuzzle.Solver {
+	return watersortpuzzle.NewIDAStarSolver(watersortpuzzle.IDAStarWithMoveOrdering(watersortpuzzle.MoveOrderingNone))
+}
+
+func (s *IDAStarNoMoveOrderingSolverSuite) SetupSuite() {
+	s.NewSolverFunc = idaStarNoMoveOrderingFactoryMethod
+}
+
+func TestIDAStarNoMoveOrderingSolver(t *testing.T) {
+	suite.Run(t, new(IDAStarNoMoveOrderingSolverSuite))
+}
+
+type IDAStarNoTranspositionTableSolverSuite struct {
+	solvertest.SolverSuite
+}
+
+func idaStarNoTranspositionTableFactoryMethod() watersortpuzzle.Solver {
+	return watersortpuzzle.NewIDAStarSolver(watersortpuzzle.IDAStarWithTranspositionTable(0, watersortpuzzle.ReplaceAlways))
+}
+
+func (s *IDAStarNoTranspositionTableSolverSuite) SetupSuite() {
+	s.NewSolverFunc = idaStarNoTranspositionTableFactoryMethod
+}
+
+func TestIDAStarNoTranspositionTableSolver(t *testing.T) {
+	suite.Run(t, new(IDAStarNoTranspositionTableSolverSuite))
+}
+
+type IDAStarSmallTranspositionTableSolverSuite struct {
+	solvertest.SolverSuite
+}
+
+func idaStarSmallTranspositionTableFactoryMethod() watersortpuzzle.Solver {
+	return watersortpuzzle.NewIDAStarSolver(watersortpuzzle.IDAStarWithTranspositionTable(64, watersortpuzzle.ReplaceAlways))
+}
+
+func (s *IDAStarSmallTranspositionTableSolverSuite) SetupSuite() {
+	s.NewSolverFunc = idaStarSmallTranspositionTableFactoryMethod
+}
+
+func TestIDAStarSmallTranspositionTableSolver(t *testing.T) {
+	suite.Run(t, new(IDAStarSmallTranspositionTableSolverSuite))
+}
+
+// BenchmarkIDAStarTranspositionTable compares expanded states with and without the table
+// on long levels, where it is expected to pay off.
+func BenchmarkIDAStarTranspositionTable(b *testing.B) {
+	const minSteps = 20
+
+	b.Run("table", func(b *testing.B) {
+		solvertest.TemplateBenchmarkSuiteMinSteps(b, minSteps, idaStarFactoryMethod)
+	})
+	b.Run("no-table", func(b *testing.B) {
+		solvertest.TemplateBenchmarkSuiteMinSteps(b, minSteps, idaStarNoTranspositionTableFactoryMethod)
+	})
+}
+
+func TestIDAStarSolverReproducible(t *testing.T) {
+	const state = "RPPR;BRFR;OGOO;QFGQ;GBQO;BQPB;PFFG;;"
+
+	initialState := solvertest.MustState(t, state)
+
+	var expectedStats watersortpuzzle.Stats
+	var expectedSteps []watersortpuzzle.Step
+	for i := 0; i < 5; i++ {
+		solver := watersortpuzzle.NewIDAStarSolver()
+		steps, err := solver.Solve(initialState)
+		require.NoError(t, err)
+
+		if i == 0 {
+			expectedStats, expectedSteps = solver.Stats(), steps
+			continue
+		}
+		require.Equal(t, expectedStats, solver.Stats())
+		require.Equal(t, expectedSteps, steps)
+	}
+}
+
+func TestSolversDeterministic(t *testing.T) {
+	const state = "GORO;FFRO;PPFO;GPRF;GRGP;;"
+
+	initialState := solvertest.MustState(t, state)
+
+	factories := map[string]func() watersortpuzzle.Solver{
+		"astar":    aStarFactoryMethod,
+		"dijkstra": dijkstraSolverFactoryMethod,
+		"idastar":  idaStarFactoryMethod,
+	}
+	for name, factory := range factories {
+		newSolver := factory
+		t.Run(name, func(t *testing.T) {
+			expectedSteps, err := newSolver().Solve(initialState)
+			require.NoError(t, err)
+
+			for i := 0; i < 5; i++ {
+				steps, err := newSolver().Solve(initialState)
+				require.NoError(t, err)
+				require.Equal(t, expectedSteps, steps)
+			}
+		})
+	}
+}
+
+type AStarFIFOSolverSuite struct {
+	solvertest.SolverSuite
+}
+
+func aStarFIFOFactoryMethod() watersortpuzzle.Solver {
+	return watersortpuzzle.NewAStarSolver(watersortpuzzle.AStarWithTieBreaking(watersortpuzzle.TieBreakFIFO))
+}
+
+func (s *AStarFIFOSolverSuite) SetupSuite() {
+	s.NewSolverFunc = aStarFIFOFactoryMethod
+}
+
+func TestAStarFIFOSolver(t *testing.T) {
+	suite.Run(t, new(AStarFIFOSolverSuite))
+}
+
+type AStarLIFOSolverSuite struct {
+	solvertest.SolverSuite
+}
+
+func aStarLIFOFactoryMethod() watersortpuzzle.Solver {
+	return watersortpuzzle.NewAStarSolver(watersortpuzzle.AStarWithTieBreaking(watersortpuzzle.TieBreakLIFO))
+}
+
+func (s *AStarLIFOSolverSuite) SetupSuite() {
+	s.NewSolverFunc = aStarLIFOFactoryMethod
+}
+
+func TestAStarLIFOSolver(t *testing.T) {
+	suite.Run(t, new(AStarLIFOSolverSuite))
+}
+
+func BenchmarkAStarTieBreaking(b *testing.B) {
+	tieBreakings := []watersortpuzzle.TieBreaking{
+		watersortpuzzle.TieBreakLargerG,
+		watersortpuzzle.TieBreakFIFO,
+		watersortpuzzle.TieBreakLIFO,
+	}
+	for _, tieBreaking := range tieBreakings {
+		tt := tieBreaking
+		b.Run(tt.String(), func(b *testing.B) {
+			solvertest.TemplateBenchmarkSuite(b, func() watersortpuzzle.Solver {
+				return watersortpuzzle.NewAStarSolver(watersortpuzzle.AStarWithTieBreaking(tt))
+			})
+		})
+	}
+}
+
+type ExternalBFSSolverSuite struct {
+	solvertest.SolverSuite
+}
+
+func externalBFSFactoryMethod() watersortpuzzle.Solver {
+	return watersortpuzzle.NewExternalBFSSolver(watersortpuzzle.ExternalBFSWithRunSize(1000))
+}
+
+func (s *ExternalBFSSolverSuite) SetupSuite() {
+	s.NewSolverFunc = externalBFSFactoryMethod
+	s.MaxFlasks = 7
+}
+
+func TestExternalBFSSolver(t *testing.T) {
+	suite.Run(t, new(ExternalBFSSolverSuite))
+}
+
+func TestExternalBFSSolverResume(t *testing.T) {
+	const state = "FPGG;OFPF;FORG;OGRP;RORP;;"
+
+	initialState := solvertest.MustState(t, state)
+
+	dir := t.TempDir()
+	solver := watersortpuzzle.NewExternalBFSSolver(watersortpuzzle.ExternalBFSWithScratchDir(dir))
+	expectedSteps, err := solver.Solve(initialState)
+	require.NoError(t, err)
+	require.Len(t, expectedSteps, 16)
+
+	resumedSolver := watersortpuzzle.NewExternalBFSSolver(watersortpuzzle.ExternalBFSWithScratchDir(dir))
+	steps, err := resumedSolver.Solve(initialState)
+	require.NoError(t, err)
+	require.Equal(t, expectedSteps, steps)
+	require.Less(t, resumedSolver.Stats().Steps, solver.Stats().Steps)
+
+	otherState := solvertest.MustState(t, "O;OOO")
+	_, err = watersortpuzzle.NewExternalBFSSolver(watersortpuzzle.ExternalBFSWithScratchDir(dir)).Solve(otherState)
+	require.Error(t, err)
+}
+
+func TestExternalBFSSolverStatsPerSolve(t *testing.T) {
+	initialState := solvertest.MustState(t, "GORO;FFRO;PPFO;GPRF;GRGP;;")
+
+	solver := externalBFSFactoryMethod()
+	_, err := solver.Solve(initialState)
+	require.NoError(t, err)
+	stats := solver.(watersortpuzzle.SolverWithStats).Stats()
+	require.Positive(t, stats.Steps)
+
+	_, err = solver.Solve(initialState)
+	require.NoError(t, err)
+	require.Equal(t, stats, solver.(watersortpuzzle.SolverWithStats).Stats())
+}
+
+func TestExternalBFSSolverNotExist(t *testing.T) {
+	initialState := solvertest.MustState(t, "GOFP;GOOB;")
+
+	_, err := externalBFSFactoryMethod().Solve(initialState)
+	require.ErrorIs(t, err, watersortpuzzle.ErrNotExist)
+}
@@ -1,192 +1,43 @@
 package solvertest
 
 import (
+	"bytes"
+	_ "embed"
+	"flag"
 	"fmt"
 	"log"
-	"strings"
 	"testing"
 
 	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/levelpack"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
-func (s *SolverSuite) TestSolver() {
-	testCases := []struct {
-		state         string
-		expectedSteps int
-	}{
-		{
-			state:         "O;OOO",
-			expectedSteps: 1,
-		},
-		{
-			state:         "FOFO;OFOF;",
-			expectedSteps: 7,
-		},
-		{
-			state:         "FORF;OORF;RFOR;;",
-			expectedSteps: 10,
-		},
-		{
-			state:         "FROO;FRFR;OFRO;;",
-			expectedSteps: 10,
-		},
-		{
-			state:         "RGGG;ORPG;PORO;FPOP;FFFR;;",
-			expectedSteps: 12,
-		},
-		{
-			state:         "GORO;FFRO;PPFO;GPRF;GRGP;;",
-			expectedSteps: 15,
-		},
-		{
-			state:         "GOGF;OPPO;PRFR;FRGP;FGRO;;",
-			expectedSteps: 16,
-		},
-		{
-			state:         "PRFP;RGGO;ROOP;PRGF;GOFF;;",
-			expectedSteps: 14,
-		},
-		{
-			state:         "FPFB;PPGB;OOQO;BRPO;FGRQ;QFRR;QBGG;;",
-			expectedSteps: 20,
-		},
-		{
-			state:         "FPGG;OFPF;FORG;OGRP;RORP;;",
-			expectedSteps: 16,
-		},
-		{
-			state:         "FPGR;OGGB;PQOR;GRFB;BPQB;POFQ;QRFO;;",
-			expectedSteps: 22,
-		},
-		{
-			state:         "QFFF;FQPO;QOQG;ROGP;RBPR;OBGB;PGBR;;",
-			expectedSteps: 21,
-		},
-		{
-			state:         "RPPR;BRFR;OGOO;QFGQ;GBQO;BQPB;PFFG;;",
-			expectedSteps: 21,
-		},
-		{
-			state:         "BQFB;PFRG;FPGF;BRQO;GOBG;RPOR;OPQQ;;",
-			expectedSteps: 22,
-		},
-		{
-			state:         "GOFR;OPRG;OFRG;PFFG;PPRO;;",
-			expectedSteps: 16,
-		},
-		{
-			state:         "GRPP;GBPB;FOQQ;OPGQ;FGBR;FFBQ;OORR;;",
-			expectedSteps: 20,
-		},
-		{
-			state:         "ORRF;PGRO;FFGR;GOPF;OPGP;;",
-			expectedSteps: 15,
-		},
-		{
-			state:         "BBFG;QROP;RGOF;QFRP;QOPP;GBFB;GQRO;;",
-			expectedSteps: 22,
-		},
-		{
-			state:         "GRPO;PRFB;OQOB;PGFB;PQRB;QGGR;FFOQ;;",
-			expectedSteps: 21,
-		},
-		{
-			state:         "RFFF;GGOO;GRPO;RGOP;PRPF;;",
-			expectedSteps: 13,
-		},
-		{
-			state:         "ORBB;GPPG;QFOG;PFQR;OQPG;RROB;BFFQ;;",
-			expectedSteps: 19,
-		},
-		{
-			state:         "OORP;RGGF;ORPP;PFFG;FRGO;;",
-			expectedSteps: 13,
-		},
-		{
-			state:         "OQBF;PPRP;OQGQ;GFPR;FFBQ;ROOB;BGGR;;",
-			expectedSteps: 19,
-		},
-		{
-			state:         "QBPO;BGGP;FOFO;PBGF;QRGF;BQQR;RORP;;",
-			expectedSteps: 22,
-		},
-		{
-			state:         "FGPT;BTHF;FQGO;POOB;QRRP;FOHG;GRTB;QHRH;PBQT;;",
-			expectedSteps: 29,
-		},
-		{
-			state:         "GOPO;OFTQ;TQRP;BHQR;GFRH;QPHR;BGOG;FBBT;HTPF;;",
-			expectedSteps: 28,
-		},
-		{
-			state:         "RGGR;BFOP;QQPF;BGBO;GOBF;PQQR;PFOR;;",
-			expectedSteps: 21,
-		},
-		{
-			state:         "TRFH;QFOO;QGQG;THBT;BRRB;FPQP;ORPF;OPBH;HGTG;;",
-			expectedSteps: 28,
-		},
-		{
-			state:         "BRQF;GRFG;GFBP;RRGP;QBOP;QOPB;OOFQ;;",
-			expectedSteps: 21,
-		},
-		{
-			state:         "QGFH;QTGG;OQRP;BBTH;HFRB;RFOR;PTBQ;POOH;GPTF;;",
-			expectedSteps: 27,
-		},
-		{
-			state:         "FBHB;FRHT;QTFF;RPOG;QGPR;OGGH;HQTR;TQPO;OBBP;;",
-			expectedSteps: 27,
-		},
-		{
-			state:         "FBPG;ROQP;BFFO;POBR;PFGO;QGBR;GQRQ;;",
-			expectedSteps: 22,
-		},
-		{
-			state:         "OHTP;TGFR;FGBF;ORRB;PTQT;HFBQ;QOHG;RPHP;BGQO;;",
-			expectedSteps: 28,
-		},
-		{
-			state:         "POGR;OBFP;OQGP;PQRG;BQQB;GRFO;FBRF;;",
-			expectedSteps: 22,
-		},
-		{
-			state:         "ROGB;PTQH;BQGP;HOOG;ROTR;PFTT;HBFQ;FRBP;QFHG;;",
-			expectedSteps: 28,
-		},
-		{
-			state:         "GPBH;PBBF;RORR;QTQH;BPHG;TTOG;ROQH;GFFO;FPTQ;;",
-			expectedSteps: 26,
-		},
-		{
-			state:         "QTGO;HBFQ;OHFB;GQHR;TGTP;PBRT;PBRP;GQOH;ROFF;;",
-			expectedSteps: 28,
-		},
-		{
-			state:         "GTFH;HORF;BHQP;PGRO;BBGO;QQOT;GFFR;HBPT;QRTP;;",
-			expectedSteps: 28,
-		},
-		{
-			state:         "BRGO;BGFF;QORB;GPPF;PRQO;RQBO;FGQP;;",
-			expectedSteps: 21,
-		},
-		{
-			state:         "GBRP;FFFG;FROG;OROB;BPQQ;HHTO;QHGB;HPPT;TTRQ;;",
-			expectedSteps: 24,
-		},
-		{
-			state:         "YOQG;BHTR;TGPH;WRPY;TWFH;YTQH;VBQO;PBVR;GBFF;OPWV;OYGQ;FVWR;;",
-			expectedSteps: 38,
-		},
+// defaultLevels are the first rounds of the game and some harder levels.
+//
+//go:embed levels.json
+var defaultLevels []byte
+
+var levelPackPath = flag.String("levelpack", "", "Level pack to run solver suites on instead of the default one")
+
+// levels returns levels of pack given by -levelpack flag or the default ones.
+func levels() (*levelpack.Pack, error) {
+	if *levelPackPath != "" {
+		return levelpack.Load(*levelPackPath)
 	}
+	return levelpack.Read(bytes.NewReader(defaultLevels))
+}
 
-	for i, testCase := range testCases {
-		tt := testCase
+func (s *SolverSuite) TestSolver() {
+	pack, err := levels()
+	s.Require().NoError(err)
 
-		s.Run(fmt.Sprintf("Test %d", i), func() {
-			testFlasks := len(strings.Split(tt.state, ";"))
+	for _, level := range pack.Levels {
+		level := level
+
+		s.Run(fmt.Sprintf("Level %d", level.Number), func() {
+			testFlasks := len(level.State)
 
 			if s.MaxFlasks != 0 && s.MaxFlasks < testFlasks {
 				s.T().Skipf("Test with %d flasks skipped, MaxFlasks is %d", testFlasks, s.MaxFlasks)
@@ -194,9 +45,7 @@ func (s *SolverSuite) TestSolver() {
 
 			solver := s.NewSolverFunc()
 
-			var initialState watersortpuzzle.State
-			s.Require().NoError(initialState.FromString(tt.state))
-
+			initialState := level.State
 			steps, err := solver.Solve(initialState)
 			s.Require().NoError(err)
 
@@ -209,7 +58,9 @@ func (s *SolverSuite) TestSolver() {
 			if statsSolver, ok := solver.(watersortpuzzle.SolverWithStats); ok {
 				log.Printf("%+v, Path length: %d\n", statsSolver.Stats(), len(steps))
 			}
-			s.Assert().Equal(tt.expectedSteps, len(steps))
+			if level.OptimalSteps != 0 {
+				s.Assert().Equal(level.OptimalSteps, len(steps))
+			}
 			s.Assert().True(state.IsTerminal())
 		})
 	}
@@ -230,6 +81,42 @@ func TemplateBenchmarkSolve(b *testing.B, newSolverFunc func() watersortpuzzle.S
 	}
 }
 
+// TemplateBenchmarkSuite benchmarks solver on every suite case.
+// Solvers with stats also report number of expanded states.
+func TemplateBenchmarkSuite(b *testing.B, newSolverFunc func() watersortpuzzle.Solver) {
+	TemplateBenchmarkSuiteMinSteps(b, 0, newSolverFunc)
+}
+
+// TemplateBenchmarkSuiteMinSteps is like TemplateBenchmarkSuite, but only for suite cases
+// with optimal solution of at least minSteps. Cases of unknown length are skipped too.
+func TemplateBenchmarkSuiteMinSteps(b *testing.B, minSteps int, newSolverFunc func() watersortpuzzle.Solver) {
+	pack, err := levels()
+	require.NoError(b, err)
+
+	for _, level := range pack.Levels {
+		if level.OptimalSteps < minSteps {
+			continue
+		}
+		initialState := level.State
+
+		b.Run(fmt.Sprintf("Level %d", level.Number), func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			var expansions int
+			for i := 0; i < b.N; i++ {
+				solver := newSolverFunc()
+				_, err := solver.Solve(initialState)
+				require.NoError(b, err)
+
+				if statsSolver, ok := solver.(watersortpuzzle.SolverWithStats); ok {
+					expansions += statsSolver.Stats().Steps
+				}
+			}
+			b.ReportMetric(float64(expansions)/float64(b.N), "expansions/op")
+		})
+	}
+}
+
 type SolverSuite struct {
 	suite.Suite
 	NewSolverFunc func() watersortpuzzle.Solver
@@ -0,0 +1,17 @@
+package solvertest
+
+import (
+	"testing"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/stretchr/testify/require"
+)
+
+// MustState parses state from string and fails the test on error.
+func MustState(t testing.TB, s string) watersortpuzzle.State {
+	t.Helper()
+
+	var state watersortpuzzle.State
+	require.NoError(t, state.FromString(s))
+	return state
+}
@@ -7,6 +7,15 @@ import (
 	"strings"
 )
 
+// Errors of illegal moves. MoveError wraps one of them.
+var (
+	ErrNoSuchFlask   = errors.New("no such flask")
+	ErrSameFlask     = errors.New("cannot pour flask into itself")
+	ErrEmptyFlask    = errors.New("cannot pour from empty flask")
+	ErrFullFlask     = errors.New("cannot pour into full flask")
+	ErrColorMismatch = errors.New("cannot pour onto another color")
+)
+
 // State of the game field. It is represented as ordered array of flasks.
 type State []Flask
 
@@ -20,6 +29,17 @@ func (s State) IsTerminal() bool {
 	return true
 }
 
+// finishedFlasks returns number of non-empty flasks filled with one color.
+func (s State) finishedFlasks() int {
+	var finished int
+	for _, f := range s {
+		if !f.IsEmpty() && f.IsFinished() {
+			finished++
+		}
+	}
+	return finished
+}
+
 // Heuristic is a monotonic lower estimate of number of steps to reach terminal state.
 // Monotonic means h(currentState) >= h(currentState with one step forward).
 func (s State) Heuristic() int {
@@ -116,7 +136,7 @@ func (s State) collectFlasksInfo() (map[Color]stepChoice, []int, []int) {
 func (s State) generateStatesFromSteps(steps []Step) []State {
 	var newStates []State
 	for _, step := range steps {
-		newState, err := s.Step(step)
+		newState, err := s.step(step)
 		if err != nil {
 			panic("logic error: cannot pour in generate steps")
 		}
@@ -126,29 +146,57 @@ func (s State) generateStatesFromSteps(steps []Step) []State {
 	return newStates
 }
 
-func (s State) getNonEmptyFlasksSteps(mp map[Color]stepChoice) []State {
-	var newStates []State
+func (s State) getNonEmptyFlasksSteps(mp map[Color]stepChoice) []Step {
+	var steps []Step
 	for _, choice := range mp {
-		steps := choice.steps()
-		newStates = append(newStates, s.generateStatesFromSteps(steps)...)
+		steps = append(steps, choice.steps()...)
 	}
-	return newStates
+	return steps
 }
 
-func (s State) getEmptyFlaskSteps(nonEmptyFlasks, emptyFlasks []int) []State {
+func (s State) getEmptyFlaskSteps(nonEmptyFlasks, emptyFlasks []int) []Step {
 	var steps []Step
 	for _, nonEmptyIdx := range nonEmptyFlasks {
 		for _, emptyIdx := range emptyFlasks {
 			steps = append(steps, Step{From: nonEmptyIdx, To: emptyIdx})
 		}
 	}
-	return s.generateStatesFromSteps(steps)
+	return steps
 }
 
-// ReachableStates from current one in one step.
-func (s State) ReachableStates() []State {
+// LegalSteps returns all steps, which are legal by the game rules, ordered by (From, To).
+// Unlike ReachableStates, they include steps, which pour only a part of the top color tower.
+func (s State) LegalSteps() []Step {
+	var steps []Step
+	for from := range s {
+		for to := range s {
+			if step := (Step{From: from, To: to}); s.validateStep(step) == nil {
+				steps = append(steps, step)
+			}
+		}
+	}
+	return steps
+}
+
+// solverSteps returns steps pouring the whole top color tower, ordered by (From, To).
+func (s State) solverSteps() []Step {
 	mp, nonEmptyFlasks, emptyFlasks := s.collectFlasksInfo()
-	return append(s.getNonEmptyFlasksSteps(mp), s.getEmptyFlaskSteps(nonEmptyFlasks, emptyFlasks)...)
+	steps := append(s.getNonEmptyFlasksSteps(mp), s.getEmptyFlaskSteps(nonEmptyFlasks, emptyFlasks)...)
+	sort.Slice(steps, func(i, j int) bool {
+		if steps[i].From != steps[j].From {
+			return steps[i].From < steps[j].From
+		}
+		return steps[i].To < steps[j].To
+	})
+	return steps
+}
+
+// ReachableStates from current one in one step, which pours the whole top color tower.
+// Solvers search only such steps.
+// States are ordered by (From, To) of steps leading to them,
+// so the order doesn't depend on map iteration and solvers are deterministic.
+func (s State) ReachableStates() []State {
+	return s.generateStatesFromSteps(s.solverSteps())
 }
 
 // Copy state for modification.
@@ -192,8 +240,76 @@ func (s *State) FromString(str string) error {
 	return nil
 }
 
+// MoveError describes why a step is illegal.
+type MoveError struct {
+	Step Step
+	Err  error
+}
+
+func (e *MoveError) Error() string {
+	return fmt.Sprintf("illegal step %d -> %d: %s", e.Step.From+1, e.Step.To+1, e.Err.Error())
+}
+
+func (e *MoveError) Unwrap() error {
+	return e.Err
+}
+
+// validateStep checks step by the game rules. Destination flask needs room
+// for one piece at least, the rest of the tower stays in place then.
+func (s State) validateStep(step Step) error {
+	moveError := func(err error) error {
+		return &MoveError{Step: step, Err: err}
+	}
+
+	if step.From < 0 || step.From >= len(s) || step.To < 0 || step.To >= len(s) {
+		return moveError(ErrNoSuchFlask)
+	}
+	if step.From == step.To {
+		return moveError(ErrSameFlask)
+	}
+
+	from, to := &s[step.From], &s[step.To]
+	if from.IsEmpty() {
+		return moveError(ErrEmptyFlask)
+	}
+	if to.IsFull() {
+		return moveError(ErrFullFlask)
+	}
+
+	color, _ := from.Top()
+	if toColor, _ := to.Top(); !to.IsEmpty() && toColor != color {
+		return moveError(ErrColorMismatch)
+	}
+	return nil
+}
+
 // Step returns a new state, which is created via applying given step to current State.
+// Step must be legal by the game rules, otherwise *MoveError is returned.
+// As in the game, only the part of the top color tower, which fits, is poured.
 func (s State) Step(step Step) (State, error) {
+	if err := s.validateStep(step); err != nil {
+		return State{}, err
+	}
+
+	newState := s.Copy()
+	from, to := &newState[step.From], &newState[step.To]
+	topColor, height := from.Top()
+	if left := to.Left(); left < height {
+		height = left
+	}
+	size := from.Size()
+	for i := 1; i <= height; i++ {
+		from[size-i] = colorNone
+	}
+	if err := to.Pour(topColor, height); err != nil {
+		return State{}, fmt.Errorf("failed to pour: %w", err)
+	}
+	return newState, nil
+}
+
+// step is an unchecked fast version of Step for solvers. It pours the whole top color tower,
+// so it is used only for steps, where the tower fits.
+func (s State) step(step Step) (State, error) {
 	newState := s.Copy()
 	topColor, height := newState[step.From].PopTop()
 	if err := newState[step.To].Pour(topColor, height); err != nil {
@@ -0,0 +1,79 @@
+package watersortpuzzle_test
+
+import (
+	"testing"
+
+	watersortpuzzle "github.com/pkositsyn/water-sort-puzzle-solver"
+	"github.com/pkositsyn/water-sort-puzzle-solver/solvertest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateStepStrict(t *testing.T) {
+	state := solvertest.MustState(t, "GOOO;OGGG;")
+
+	_, err := state.Step(watersortpuzzle.Step{From: 0, To: 1})
+	require.ErrorIs(t, err, watersortpuzzle.ErrFullFlask)
+	_, err = state.Step(watersortpuzzle.Step{From: 2, To: 0})
+	require.ErrorIs(t, err, watersortpuzzle.ErrEmptyFlask)
+	_, err = state.Step(watersortpuzzle.Step{From: 0, To: 0})
+	require.ErrorIs(t, err, watersortpuzzle.ErrSameFlask)
+
+	newState, err := state.Step(watersortpuzzle.Step{From: 0, To: 2})
+	require.NoError(t, err)
+	require.Equal(t, "G;OGGG;OOO", newState.String())
+
+	_, err = newState.Step(watersortpuzzle.Step{From: 0, To: 2})
+	require.ErrorIs(t, err, watersortpuzzle.ErrColorMismatch)
+}
+
+func TestStateStepPartial(t *testing.T) {
+	state := solvertest.MustState(t, "GOOO;OO;")
+
+	newState, err := state.Step(watersortpuzzle.Step{From: 0, To: 1})
+	require.NoError(t, err)
+	require.Equal(t, "GO;OOOO;", newState.String())
+
+	// Solvers pour only whole towers, so they don't see the partial step.
+	require.Contains(t, state.LegalSteps(), watersortpuzzle.Step{From: 0, To: 1})
+	for _, reachable := range state.ReachableStates() {
+		require.NotEqual(t, newState, reachable)
+	}
+}
+
+func TestStateLegalSteps(t *testing.T) {
+	for _, str := range []string{"O;OOO", "FORF;OORF;RFOR;;", "GOOO;OGGG;", "AB;BA;AA;B", "OOOO;"} {
+		state := solvertest.MustState(t, str)
+
+		var expected []watersortpuzzle.Step
+		for from := range state {
+			for to := range state {
+				step := watersortpuzzle.Step{From: from, To: to}
+				if _, err := state.Step(step); err == nil {
+					expected = append(expected, step)
+				}
+			}
+		}
+		require.Equal(t, expected, state.LegalSteps(), str)
+		require.Len(t, state.ReachableStates(), len(expected), str)
+	}
+}
+
+func TestStateRender(t *testing.T) {
+	state := solvertest.MustState(t, "GOFP;GOOB;O;")
+
+	expected := "" +
+		"| P | | B | |   | |   |\n" +
+		"| F | | O | |   | |   |\n" +
+		"| O | | O | |   | |   |\n" +
+		"| G | | G | | O | |   |\n" +
+		"+---+ +---+ +---+ +---+\n" +
+		"  1     2     3     4  \n"
+	require.Equal(t, expected, state.Render(watersortpuzzle.RenderOptions{}))
+
+	colored := state.Render(watersortpuzzle.RenderOptions{
+		ANSI:    true,
+		Palette: watersortpuzzle.Palette{'O': {R: 0xff, G: 0x88, A: 0xff}},
+	})
+	require.Contains(t, colored, "\x1b[48;2;255;136;0m\x1b[38;2;0;0;0m O \x1b[0m")
+	require.Contains(t, colored, "  4  \n")
+}
@@ -0,0 +1,85 @@
+package watersortpuzzle
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// ReplacementPolicy decides which state keeps a transposition table slot,
+// when two different states collide in it.
+type ReplacementPolicy int
+
+const (
+	// ReplaceShallower keeps the state explored closer to the initial state.
+	// Such entries prune larger subtrees, so they are more valuable.
+	ReplaceShallower ReplacementPolicy = iota
+
+	// ReplaceAlways keeps the most recently explored state.
+	ReplaceAlways
+)
+
+const defaultTranspositionTableSize = 1 << 16
+
+type transpositionEntry struct {
+	key string
+	// distance from initial state at which the state was explored.
+	distance int
+	// bound is a lower estimate of f for any solution through the state
+	// reached at distance.
+	bound int
+}
+
+// transpositionTable is a bounded hash table of states already explored by IDA*.
+// Every state maps into exactly one slot, colliding states are resolved by ReplacementPolicy.
+type transpositionTable struct {
+	entries []transpositionEntry
+	policy  ReplacementPolicy
+}
+
+func newTranspositionTable(size int, policy ReplacementPolicy) *transpositionTable {
+	if size <= 0 {
+		return nil
+	}
+	return &transpositionTable{entries: make([]transpositionEntry, size), policy: policy}
+}
+
+func (t *transpositionTable) slot(key string) *transpositionEntry {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return &t.entries[h.Sum64()%uint64(len(t.entries))]
+}
+
+// lookup returns lower estimate of f for solution through the state reached at distance.
+func (t *transpositionTable) lookup(key string, distance int) (bound int, ok bool) {
+	entry := t.slot(key)
+	if entry.key != key || entry.distance > distance {
+		return 0, false
+	}
+	if entry.bound == math.MaxInt {
+		return math.MaxInt, true
+	}
+	return entry.bound + distance - entry.distance, true
+}
+
+func (t *transpositionTable) store(key string, distance, bound int) {
+	entry := t.slot(key)
+	if entry.key == key {
+		switch {
+		case distance < entry.distance:
+			entry.distance, entry.bound = distance, bound
+		case bound == math.MaxInt:
+			entry.bound = bound
+		case entry.bound != math.MaxInt:
+			// Estimate is valid for smaller distance too, just shifted.
+			if shifted := bound - distance + entry.distance; shifted > entry.bound {
+				entry.bound = shifted
+			}
+		}
+		return
+	}
+
+	if entry.key != "" && t.policy == ReplaceShallower && entry.distance < distance {
+		return
+	}
+	*entry = transpositionEntry{key: key, distance: distance, bound: bound}
+}